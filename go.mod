@@ -4,13 +4,16 @@ go 1.13
 
 require (
 	github.com/aws/aws-sdk-go v1.26.8
+	github.com/boombuler/barcode v1.0.1
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/globalsign/mgo v0.0.0-20181015135952-eeefdecb41b8
 	github.com/go-sql-driver/mysql v1.4.1
 	github.com/gorilla/handlers v1.4.2
 	github.com/gorilla/mux v1.7.3
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/justinas/alice v1.2.0
 	github.com/ssrdive/cidium v0.0.0-20200607160304-4e91c2542db5 // indirect
 	github.com/ssrdive/mysequel v0.0.0-20200607152047-bfb6d81da001
 	golang.org/x/crypto v0.0.0-20200604202706-70a84ac30bf9
+	golang.org/x/image v0.0.0-20200430140353-33d19683fad8
 )
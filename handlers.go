@@ -1,15 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
+	"github.com/ssrdive/basara/pkg/label"
 	"github.com/ssrdive/basara/pkg/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -49,6 +53,7 @@ func (app *application) authenticate(w http.ResponseWriter, r *http.Request) {
 
 	claims["username"] = u.Username
 	claims["name"] = u.Name
+	claims["type"] = u.Type
 	claims["exp"] = time.Now().Add(time.Minute * 180).Unix()
 
 	ts, err := token.SignedString(app.secret)
@@ -271,14 +276,56 @@ func (app *application) ageWise(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) search(w http.ResponseWriter, r *http.Request) {
-	search := r.URL.Query().Get("search")
+	q := r.URL.Query()
+
+	for _, param := range []string{"model", "warehouse", "min_price", "max_price", "min_age", "max_age", "document_type"} {
+		if v := q.Get(param); v != "" {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				app.clientError(w, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	page, size := 1, 50
+	var err error
+	if v := q.Get("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("size"); v != "" {
+		size, err = strconv.Atoi(v)
+		if err != nil || size < 1 || size > 500 {
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
 
-	results, err := app.warehouse.Search(search)
+	results, total, err := app.warehouse.Search(models.SearchFilter{
+		Search:          q.Get("search"),
+		Model:           q.Get("model"),
+		Warehouse:       q.Get("warehouse"),
+		PrimaryPrefix:   q.Get("primary"),
+		SecondaryPrefix: q.Get("secondary"),
+		MinPrice:        q.Get("min_price"),
+		MaxPrice:        q.Get("max_price"),
+		MinAge:          q.Get("min_age"),
+		MaxAge:          q.Get("max_age"),
+		DocumentType:    q.Get("document_type"),
+		IncludeHistory:  q.Get("include_history") == "true" || q.Get("include_history") == "1",
+		Sort:            q.Get("sort"),
+		Page:            page,
+		Size:            size,
+	})
 	if err != nil {
 		app.serverError(w, err)
 		return
 	}
 
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
 }
@@ -346,42 +393,202 @@ func (app *application) transaction(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	id, err := app.warehouse.Movement(r.PostForm)
+	id, err := app.warehouse.Movement(r.PostForm, app.username(r))
 
 	if err != nil {
-		app.serverError(w, err)
+		if errors.Is(err, models.ErrReserved) || errors.Is(err, models.ErrPeriodLocked) {
+			app.clientError(w, http.StatusConflict)
+		} else if errors.Is(err, models.ErrInvalidUnits) || errors.Is(err, models.ErrDocumentTypeNotAllowed) || errors.Is(err, models.ErrInvalidDate) {
+			app.clientError(w, http.StatusBadRequest)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%v", id)
+}
+
+func (app *application) goodsIn(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"warehouse_id", "date", "document_type", "goods"}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+	if r.PostForm.Get("supplier_id") == "" && r.PostForm.Get("purchase_order_id") == "" {
+		fmt.Println("supplier_id")
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	id, err := app.warehouse.GoodsIn(r.PostForm)
+
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else if errors.Is(err, models.ErrPurchaseOrderExceeded) || errors.Is(err, models.ErrPurchaseOrderMismatch) || errors.Is(err, models.ErrInvalidCost) || errors.Is(err, models.ErrDocumentTypeNotAllowed) || errors.Is(err, models.ErrInvalidDate) {
+			app.clientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrPeriodLocked) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%v", id)
+}
+
+func (app *application) sale(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"warehouse_id", "date", "document_type", "customer_id", "invoice_number", "goods"}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := app.warehouse.Sale(r.PostForm, app.username(r))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidUnits) || errors.Is(err, models.ErrInvalidGoods) || errors.Is(err, models.ErrDocumentTypeNotAllowed) || errors.Is(err, models.ErrInvalidDate) {
+			app.clientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrReserved) || errors.Is(err, models.ErrPeriodLocked) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
 		return
 	}
 
 	fmt.Fprintf(w, "%v", id)
 }
 
-func (app *application) secNumberModel(w http.ResponseWriter, r *http.Request) {
+func (app *application) saleDetails(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.warehouse.SaleDetails(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) createCustomer(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
 		return
 	}
 
-	dt, err := app.warehouse.SecNumberModel(r.PostForm.Get("primaryNumber"))
+	requiredParams := []string{"name", "nic", "contact", "address"}
+	optionalParams := []string{}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := app.customer.Create(requiredParams, optionalParams, r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) searchCustomers(w http.ResponseWriter, r *http.Request) {
+	search := r.URL.Query().Get("search")
 
+	results, err := app.customer.Search(search)
 	if err != nil {
 		app.serverError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dt)
+	json.NewEncoder(w).Encode(results)
 }
 
-func (app *application) goodsIn(w http.ResponseWriter, r *http.Request) {
+func (app *application) customerDetails(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.customer.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) customerPurchases(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.customer.Purchases(id)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) createSupplier(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
 		return
 	}
 
-	requiredParams := []string{"warehouse_id", "from_warehouse_id", "date", "goods"}
+	requiredParams := []string{"name", "contact", "address"}
+	optionalParams := []string{}
 	for _, param := range requiredParams {
 		if v := r.PostForm.Get(param); v == "" {
 			fmt.Println(param)
@@ -390,12 +597,1483 @@ func (app *application) goodsIn(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	id, err := app.warehouse.GoodsIn(r.PostForm)
+	id, err := app.supplier.Create(requiredParams, optionalParams, r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
 
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) allSuppliers(w http.ResponseWriter, r *http.Request) {
+	results, err := app.supplier.All()
 	if err != nil {
 		app.serverError(w, err)
 		return
 	}
 
-	fmt.Fprintf(w, "%v", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) createPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"supplier_id", "warehouse_id", "date", "items"}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := app.supplier.CreatePurchaseOrder(r.PostForm)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPurchaseOrder) {
+			app.clientError(w, http.StatusBadRequest)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) purchaseOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.supplier.PurchaseOrder(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) outstandingPurchases(w http.ResponseWriter, r *http.Request) {
+	results, err := app.supplier.Outstanding()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) createReservation(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"primary_id", "expires_at"}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+	if r.PostForm.Get("customer_id") == "" && r.PostForm.Get("warehouse_id") == "" {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	id, err := app.reservation.Create(r.PostForm, app.username(r))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidUnits) || errors.Is(err, models.ErrInvalidExpiry) {
+			app.clientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrReserved) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) releaseReservation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.reservation.Release(id, app.username(r), app.isAdmin(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else if errors.Is(err, models.ErrNotOwner) {
+			app.clientError(w, http.StatusForbidden)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) activeReservations(w http.ResponseWriter, r *http.Request) {
+	results, err := app.reservation.Active()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) valuation(w http.ResponseWriter, r *http.Request) {
+	at, err := app.asAt(r)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.report.Valuation(at)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) stockAsAt(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("date") == "" {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	at, err := app.asAt(r)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.report.StockAsAt(at, r.URL.Query().Get("warehouse"))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) ageing(w http.ResponseWriter, r *http.Request) {
+	bounds := []int{30, 60, 90}
+	if buckets := r.URL.Query().Get("buckets"); buckets != "" {
+		bounds = []int{}
+		for _, b := range strings.Split(buckets, ",") {
+			bound, err := strconv.Atoi(strings.TrimSpace(b))
+			if err != nil || bound < 0 || (len(bounds) > 0 && bound <= bounds[len(bounds)-1]) {
+				app.clientError(w, http.StatusBadRequest)
+				return
+			}
+			bounds = append(bounds, bound)
+		}
+	}
+
+	basis := r.URL.Query().Get("basis")
+	if basis != "" && basis != "warehouse" && basis != "total" {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.report.Ageing(bounds, basis == "total")
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) movementSummary(w http.ResponseWriter, r *http.Request) {
+	from, err := time.ParseInLocation("2006-01-02", r.URL.Query().Get("from"), time.Local)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	to, err := time.ParseInLocation("2006-01-02", r.URL.Query().Get("to"), time.Local)
+	if err != nil || to.Before(from) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "day"
+	}
+
+	results, err := app.report.MovementSummary(from, to.AddDate(0, 0, 1), period)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPeriod) {
+			app.clientError(w, http.StatusBadRequest)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) setReorderLevel(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"warehouse_id", "model_id", "min_quantity", "max_quantity"}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	minQuantity, err := strconv.Atoi(r.PostForm.Get("min_quantity"))
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	maxQuantity, err := strconv.Atoi(r.PostForm.Get("max_quantity"))
+	if err != nil || minQuantity < 0 || maxQuantity < minQuantity {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.reorder.Set(r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprint(w, "OK")
+}
+
+func (app *application) allReorderLevels(w http.ResponseWriter, r *http.Request) {
+	results, err := app.reorder.All()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) reorderSuggestions(w http.ResponseWriter, r *http.Request) {
+	results, err := app.reorder.Suggestions()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) unitLabel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	primaryID := vars["primary_id"]
+
+	symbology := r.URL.Query().Get("type")
+	if symbology == "" {
+		symbology = label.Code128
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if (symbology != label.Code128 && symbology != label.QR) || (format != "png" && format != "pdf") {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	unit, err := app.warehouse.LabelUnit(primaryID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if format == "pdf" {
+		err = label.PDF(&buf, []models.LabelUnit{unit}, symbology)
+	} else {
+		err = label.PNG(&buf, unit, symbology)
+	}
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+	} else {
+		w.Header().Set("Content-Type", "image/png")
+	}
+	w.Write(buf.Bytes())
+}
+
+func (app *application) documentLabels(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	symbology := r.URL.Query().Get("type")
+	if symbology == "" {
+		symbology = label.Code128
+	}
+	if symbology != label.Code128 && symbology != label.QR {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	units, err := app.warehouse.DocumentLabelUnits(id)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+	if len(units) == 0 {
+		app.notFound(w)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = label.PDF(&buf, units, symbology)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Write(buf.Bytes())
+}
+
+func (app *application) unitDetail(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	primaryID := vars["primary_id"]
+
+	result, err := app.unit.Detail(primaryID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) uploadUnitAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	primaryID := vars["primary_id"]
+
+	exists, err := app.unit.Exists(primaryID)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+	if !exists {
+		app.notFound(w)
+		return
+	}
+
+	err = r.ParseMultipartForm(10 << 20)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	s, err := app.getS3Session(app.s3endpoint, app.s3region)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	key, err := app.uploadFileToS3(s, file, header)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	id, err := app.unit.Attach(primaryID, header.Filename, key, app.username(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) lookupPrimary(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	result, err := app.unit.LookupPrimary(vars["number"])
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) lookupSecondary(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	result, err := app.unit.LookupSecondary(vars["number"])
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) lookup(w http.ResponseWriter, r *http.Request) {
+	var numbers []string
+	for _, n := range strings.Split(r.URL.Query().Get("numbers"), ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 || len(numbers) > 500 {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.unit.Lookup(numbers)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) createItemCategory(w http.ResponseWriter, r *http.Request) {
+	app.createCatalogRow(w, r, "item_category", []string{"name"})
+}
+
+func (app *application) createItem(w http.ResponseWriter, r *http.Request) {
+	app.createCatalogRow(w, r, "item", []string{"name"})
+}
+
+func (app *application) createItemDetails(w http.ResponseWriter, r *http.Request) {
+	app.createCatalogRow(w, r, "item_details", []string{"item_id", "model_id", "item_category_id", "page_no", "item_no", "foreign_id", "price"})
+}
+
+func (app *application) createCatalogRow(w http.ResponseWriter, r *http.Request, table string, requiredParams []string) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	optionalParams := []string{}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := app.item.Create(table, requiredParams, optionalParams, r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) allCatalogItems(w http.ResponseWriter, r *http.Request) {
+	results, err := app.item.All()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) updateItemDetails(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"item_id", "model_id", "item_category_id", "page_no", "item_no", "foreign_id", "price"}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err = app.item.UpdateDetails(strconv.Itoa(id), requiredParams, r.PostForm)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) deleteItemDetails(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.item.DeleteDetails(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) itemDetails(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.item.Details(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) modelItemDetails(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.item.ModelDetails(id)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) searchItemDetails(w http.ResponseWriter, r *http.Request) {
+	foreignID := r.URL.Query().Get("foreign_id")
+	if foreignID == "" {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.item.Search(foreignID)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) itemGoodsIn(w http.ResponseWriter, r *http.Request) {
+	app.itemTransaction(w, r, []string{"warehouse_id", "document_type", "date", "items"}, app.item.ItemGoodsIn)
+}
+
+func (app *application) itemMovement(w http.ResponseWriter, r *http.Request) {
+	app.itemTransaction(w, r, []string{"warehouse_id", "from_warehouse_id", "document_type", "date", "items"}, app.item.ItemMovement)
+}
+
+func (app *application) itemIssue(w http.ResponseWriter, r *http.Request) {
+	app.itemTransaction(w, r, []string{"warehouse_id", "document_type", "date", "items"}, app.item.ItemIssue)
+}
+
+func (app *application) itemTransaction(w http.ResponseWriter, r *http.Request, requiredParams []string, transaction func(url.Values) (int64, error)) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := transaction(r.PostForm)
+	if err != nil {
+		if errors.Is(err, models.ErrInsufficientStock) || errors.Is(err, models.ErrDocumentTypeNotAllowed) || errors.Is(err, models.ErrInvalidDate) || errors.Is(err, models.ErrInvalidGoods) {
+			app.clientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrPeriodLocked) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%v", id)
+}
+
+func (app *application) itemStock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.item.Stock(id)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) itemLedger(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.item.Ledger(id)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) createModelPrice(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"model_id", "cost", "dealer", "retail", "effective_from"}
+	optionalParams := []string{}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := app.model.CreatePrice(requiredParams, optionalParams, r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) modelPriceHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.model.PriceHistory(id)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) activeModelPrices(w http.ResponseWriter, r *http.Request) {
+	date := time.Now()
+	if d := r.URL.Query().Get("date"); d != "" {
+		var err error
+		date, err = time.ParseInLocation("2006-01-02", d, time.Local)
+		if err != nil {
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := app.model.ActivePrices(date)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) addDocumentCost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"cost_type", "amount", "method"}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	cid, err := app.warehouse.AddDocumentCost(id, r.PostForm.Get("cost_type"), r.PostForm.Get("amount"), r.PostForm.Get("method"), app.username(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else if errors.Is(err, models.ErrInvalidCost) {
+			app.clientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrPeriodLocked) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", cid)
+}
+
+func (app *application) documentCosts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.warehouse.DocumentCosts(id)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) createDocumentSeries(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{"document_type_id", "warehouse_id", "prefix", "padding"}
+	optionalParams := []string{}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := app.warehouse.CreateSeries(requiredParams, optionalParams, r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) allDocumentSeries(w http.ResponseWriter, r *http.Request) {
+	results, err := app.warehouse.AllSeries()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) searchDocuments(w http.ResponseWriter, r *http.Request) {
+	number := r.URL.Query().Get("number")
+	if number == "" {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.warehouse.SearchDocuments(number)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// documentTypeColumns are the document type fields set on create and update
+var documentTypeColumns = []string{"name", "inbound", "transfer", "outbound", "adjustment", "requires_approval"}
+
+// documentTypeWarehouseColumns restrict the warehouse types a document type
+// moves stock between. They are left empty to allow any warehouse.
+var documentTypeWarehouseColumns = []string{"source_warehouse_type_id", "destination_warehouse_type_id"}
+
+func (app *application) createDocumentType(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	for _, param := range documentTypeColumns {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := app.documentType.Create(documentTypeColumns, documentTypeWarehouseColumns, r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) updateDocumentType(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	for _, param := range documentTypeColumns {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err = app.documentType.Update(strconv.Itoa(id), append(documentTypeColumns, documentTypeWarehouseColumns...), r.PostForm)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else if errors.Is(err, models.ErrDocumentTypeInUse) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) deleteDocumentType(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.documentType.Delete(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else if errors.Is(err, models.ErrDocumentTypeInUse) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) allDocumentTypes(w http.ResponseWriter, r *http.Request) {
+	results, err := app.documentType.All()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) documentTypeDetails(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.documentType.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// approvalRuleConditions are the numeric fields an approval rule matches
+// movements on
+var approvalRuleConditions = []string{"document_type_id", "from_warehouse_id", "to_warehouse_id", "min_units", "min_value"}
+
+func (app *application) createApprovalRule(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// A rule needs at least one condition, one without would hold every
+	// movement
+	conditions := 0
+	for _, param := range approvalRuleConditions {
+		v := r.PostForm.Get(param)
+		if v == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+		conditions++
+	}
+	if conditions == 0 {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams := []string{}
+	optionalParams := append(append([]string{}, approvalRuleConditions...), "approver")
+
+	id, err := app.warehouse.CreateApprovalRule(requiredParams, optionalParams, r.PostForm)
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) deleteApprovalRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.warehouse.DeleteApprovalRule(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) approvalRules(w http.ResponseWriter, r *http.Request) {
+	results, err := app.warehouse.ApprovalRules()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) pendingApprovals(w http.ResponseWriter, r *http.Request) {
+	results, err := app.warehouse.PendingApprovals(app.username(r), app.isAdmin(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) approveDocument(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.warehouse.Approve(id, app.username(r), app.isAdmin(r))
+	if err != nil {
+		app.approvalError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) rejectDocument(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if r.PostForm.Get("reason") == "" {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.warehouse.Reject(id, app.username(r), r.PostForm.Get("reason"), app.isAdmin(r))
+	if err != nil {
+		app.approvalError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) approvalError(w http.ResponseWriter, err error) {
+	if errors.Is(err, models.ErrNoRecord) {
+		app.notFound(w)
+	} else if errors.Is(err, models.ErrNotApprover) {
+		app.clientError(w, http.StatusForbidden)
+	} else if errors.Is(err, models.ErrNotPending) || errors.Is(err, models.ErrReserved) || errors.Is(err, models.ErrPeriodLocked) {
+		app.clientError(w, http.StatusConflict)
+	} else if errors.Is(err, models.ErrInvalidUnits) || errors.Is(err, models.ErrInvalidDate) {
+		app.clientError(w, http.StatusBadRequest)
+	} else {
+		app.serverError(w, err)
+	}
+}
+
+// draftParams are the header fields a draft needs to be posted as a goods-in
+// or a movement
+var draftParams = map[string][]string{
+	"goods_in": {"warehouse_id", "date", "document_type"},
+	"movement": {"warehouse_id", "from_warehouse_id", "date", "document_type"},
+}
+
+func (app *application) createDraft(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	requiredParams, ok := draftParams[r.PostForm.Get("kind")]
+	if !ok {
+		fmt.Println("kind")
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+	for _, param := range requiredParams {
+		if v := r.PostForm.Get(param); v == "" {
+			fmt.Println(param)
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if r.PostForm.Get("kind") == "goods_in" && r.PostForm.Get("supplier_id") == "" && r.PostForm.Get("purchase_order_id") == "" {
+		fmt.Println("supplier_id")
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	id, err := app.warehouse.CreateDraft(r.PostForm.Get("kind"), r.PostForm, app.username(r))
+	if err != nil {
+		app.draftError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) addDraftItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if r.PostForm.Get("primary_number") == "" {
+		fmt.Println("primary_number")
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	item, err := app.warehouse.AddDraftItem(id, models.DraftItem{
+		Model:           r.PostForm.Get("model"),
+		PrimaryNumber:   r.PostForm.Get("primary_number"),
+		SecondaryNumber: r.PostForm.Get("secondary_number"),
+		Price:           r.PostForm.Get("price"),
+	}, app.username(r))
+	if err != nil {
+		app.draftError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func (app *application) removeDraftItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if r.PostForm.Get("primary_number") == "" {
+		fmt.Println("primary_number")
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.warehouse.RemoveDraftItem(id, r.PostForm.Get("primary_number"), app.username(r))
+	if err != nil {
+		app.draftError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) postDraft(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	did, err := app.warehouse.PostDraft(id, app.username(r))
+	if err != nil {
+		app.draftError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", did)
+}
+
+func (app *application) discardDraft(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.warehouse.DiscardDraft(id, app.username(r))
+	if err != nil {
+		app.draftError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) draft(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	result, err := app.warehouse.Draft(id, app.username(r))
+	if err != nil {
+		app.draftError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) openDrafts(w http.ResponseWriter, r *http.Request) {
+	results, err := app.warehouse.OpenDrafts(app.username(r))
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) draftError(w http.ResponseWriter, err error) {
+	if errors.Is(err, models.ErrNoRecord) {
+		app.notFound(w)
+	} else if errors.Is(err, models.ErrNotOwner) {
+		app.clientError(w, http.StatusForbidden)
+	} else if errors.Is(err, models.ErrDraftClosed) || errors.Is(err, models.ErrReserved) || errors.Is(err, models.ErrPeriodLocked) {
+		app.clientError(w, http.StatusConflict)
+	} else if errors.Is(err, models.ErrInvalidUnits) || errors.Is(err, models.ErrPurchaseOrderExceeded) || errors.Is(err, models.ErrPurchaseOrderMismatch) || errors.Is(err, models.ErrInvalidCost) || errors.Is(err, models.ErrDocumentTypeNotAllowed) || errors.Is(err, models.ErrInvalidDate) || errors.Is(err, models.ErrInvalidDraft) {
+		app.clientError(w, http.StatusBadRequest)
+	} else {
+		app.serverError(w, err)
+	}
+}
+
+func (app *application) setLockDate(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if r.PostForm.Get("lock_date") == "" {
+		fmt.Println("lock_date")
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	id, err := app.warehouse.SetLockDate(r.PostForm.Get("lock_date"), app.username(r))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidDate) {
+			app.clientError(w, http.StatusBadRequest)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%d", id)
+}
+
+func (app *application) lockDate(w http.ResponseWriter, r *http.Request) {
+	result, err := app.warehouse.LockDate()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (app *application) closePeriod(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if r.PostForm.Get("period") == "" {
+		fmt.Println("period")
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	err = app.report.ClosePeriod(r.PostForm.Get("period"), app.username(r))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPeriod) {
+			app.clientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrPeriodLocked) || errors.Is(err, models.ErrPeriodUnsettled) {
+			app.clientError(w, http.StatusConflict)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%s", r.PostForm.Get("period"))
+}
+
+func (app *application) periods(w http.ResponseWriter, r *http.Request) {
+	results, err := app.report.Periods()
+	if err != nil {
+		app.serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (app *application) periodValuation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	result, err := app.report.PeriodValuation(vars["period"])
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPeriod) {
+			app.clientError(w, http.StatusBadRequest)
+		} else if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
 }
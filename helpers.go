@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"runtime/debug"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -36,6 +38,55 @@ func (app *application) extractUser(r *http.Request) jwt.Claims {
 	return ctx.Value(contextKey("User")).(jwt.MapClaims)
 }
 
+func (app *application) username(r *http.Request) string {
+	claims := app.extractUser(r).(jwt.MapClaims)
+	username, _ := claims["username"].(string)
+	return username
+}
+
+// isAdmin reports whether the user of the request has the admin user type
+func (app *application) isAdmin(r *http.Request) bool {
+	claims := app.extractUser(r).(jwt.MapClaims)
+	userType, _ := claims["type"].(string)
+	return userType == app.adminType
+}
+
+// asAt returns the end of the day given in the date query parameter,
+// or the current time when it is not given
+func (app *application) asAt(r *http.Request) (time.Time, error) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		return time.Now(), nil
+	}
+
+	d, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.AddDate(0, 0, 1), nil
+}
+
+// smsClient sends text messages without waiting on a stalled endpoint
+var smsClient = &http.Client{Timeout: 10 * time.Second}
+
+func (app *application) sendMessage(telephone, message string) error {
+	q := url.Values{}
+	q.Set("key", app.rAPIKey)
+	q.Set("to", telephone)
+	q.Set("message", message)
+
+	resp, err := smsClient.Get(app.smsEndpoint + "?" + q.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sending message to %s: %s", telephone, resp.Status)
+	}
+	return nil
+}
+
 func (app *application) getS3Session(endpoint, region string) (*session.Session, error) {
 	s, err := session.NewSession(&aws.Config{
 		Endpoint: &endpoint,
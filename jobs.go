@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// reorderAlerts checks the stock levels on every tick of the interval
+func (app *application) reorderAlerts(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		app.sendReorderAlerts()
+	}
+}
+
+// sendReorderAlerts texts the warehouse and model pairs that have fallen
+// below their minimum since the last check to the alert recipients. A pair
+// is alerted on again only after its stock has recovered and fallen again.
+func (app *application) sendReorderAlerts() {
+	suggestions, err := app.reorder.AlertsDue()
+	if err != nil {
+		app.errorLog.Println(err)
+		return
+	}
+	if len(suggestions) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Low stock")
+	for _, s := range suggestions {
+		fmt.Fprintf(&b, "\n%s %s: %d on hand, reorder %d", s.Warehouse, s.Model, s.OnHand, s.Suggested)
+	}
+	message := b.String()
+	app.infoLog.Println(message)
+
+	sent := app.smsEndpoint == "" || len(app.alertTelephones) == 0
+	if app.smsEndpoint != "" {
+		for _, telephone := range app.alertTelephones {
+			if err := app.sendMessage(telephone, message); err != nil {
+				app.errorLog.Println(err)
+				continue
+			}
+			sent = true
+		}
+	}
+	if !sent {
+		return
+	}
+
+	if err := app.reorder.MarkAlerted(suggestions); err != nil {
+		app.errorLog.Println(err)
+	}
+}
@@ -6,27 +6,39 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/ssrdive/basara/pkg/models/mysql"
 )
 
 type application struct {
-	errorLog   *log.Logger
-	infoLog    *log.Logger
-	secret     []byte
-	s3id       string
-	s3secret   string
-	s3endpoint string
-	s3region   string
-	s3bucket   string
-	rAPIKey    string
-	aAPIKey    string
-	runtimeEnv string
-	user       *mysql.UserModel
-	dropdown   *mysql.DropdownModel
-	model      *mysql.MModel
-	warehouse  *mysql.Warehouse
+	errorLog        *log.Logger
+	infoLog         *log.Logger
+	secret          []byte
+	s3id            string
+	s3secret        string
+	s3endpoint      string
+	s3region        string
+	s3bucket        string
+	rAPIKey         string
+	aAPIKey         string
+	runtimeEnv      string
+	smsEndpoint     string
+	alertTelephones []string
+	adminType       string
+	user            *mysql.UserModel
+	dropdown        *mysql.DropdownModel
+	model           *mysql.MModel
+	warehouse       *mysql.Warehouse
+	customer        *mysql.CustomerModel
+	supplier        *mysql.SupplierModel
+	reservation     *mysql.ReservationModel
+	report          *mysql.ReportModel
+	reorder         *mysql.ReorderModel
+	unit            *mysql.UnitModel
+	item            *mysql.ItemModel
+	documentType    *mysql.DocumentTypeModel
 }
 
 func main() {
@@ -41,6 +53,10 @@ func main() {
 	rAPIKey := flag.String("rAPIKey", "", "Randeepa Text Message API Key")
 	aAPIKey := flag.String("aAPIKey", "", "Randeepa Text Message API Key")
 	runtimeEnv := flag.String("renv", "prod", "Runtime environment mode")
+	smsEndpoint := flag.String("smsEndpoint", "", "Text message API endpoint")
+	alertTelephones := flag.String("alertTelephones", "", "Comma separated numbers to text stock alerts to")
+	adminType := flag.String("adminType", "Admin", "User type allowed to lock and close periods")
+	reorderAlertInterval := flag.Duration("reorderAlertInterval", 0, "Interval between low stock checks, 0 disables them")
 	flag.Parse()
 
 	infoLog := log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
@@ -54,21 +70,39 @@ func main() {
 	defer db.Close()
 
 	app := &application{
-		errorLog:   errorLog,
-		infoLog:    infoLog,
-		secret:     []byte(*secret),
-		s3id:       *s3id,
-		s3secret:   *s3secret,
-		s3endpoint: *s3endpoint,
-		s3region:   *s3region,
-		s3bucket:   *s3bucket,
-		rAPIKey:    *rAPIKey,
-		aAPIKey:    *aAPIKey,
-		runtimeEnv: *runtimeEnv,
-		user:       &mysql.UserModel{DB: db},
-		dropdown:   &mysql.DropdownModel{DB: db},
-		model:      &mysql.MModel{DB: db},
-		warehouse:  &mysql.Warehouse{DB: db},
+		errorLog:     errorLog,
+		infoLog:      infoLog,
+		secret:       []byte(*secret),
+		s3id:         *s3id,
+		s3secret:     *s3secret,
+		s3endpoint:   *s3endpoint,
+		s3region:     *s3region,
+		s3bucket:     *s3bucket,
+		rAPIKey:      *rAPIKey,
+		aAPIKey:      *aAPIKey,
+		runtimeEnv:   *runtimeEnv,
+		smsEndpoint:  *smsEndpoint,
+		adminType:    *adminType,
+		user:         &mysql.UserModel{DB: db},
+		dropdown:     &mysql.DropdownModel{DB: db},
+		model:        &mysql.MModel{DB: db},
+		warehouse:    &mysql.Warehouse{DB: db},
+		customer:     &mysql.CustomerModel{DB: db},
+		supplier:     &mysql.SupplierModel{DB: db},
+		reservation:  &mysql.ReservationModel{DB: db},
+		report:       &mysql.ReportModel{DB: db},
+		reorder:      &mysql.ReorderModel{DB: db},
+		unit:         &mysql.UnitModel{DB: db},
+		item:         &mysql.ItemModel{DB: db},
+		documentType: &mysql.DocumentTypeModel{DB: db},
+	}
+
+	if *alertTelephones != "" {
+		app.alertTelephones = strings.Split(*alertTelephones, ",")
+	}
+
+	if *reorderAlertInterval > 0 {
+		go app.reorderAlerts(*reorderAlertInterval)
 	}
 
 	srv := &http.Server{
@@ -0,0 +1,217 @@
+// Package label renders barcode and QR labels for units
+package label
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/ssrdive/basara/pkg/models"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Symbologies supported on labels
+const (
+	Code128 = "code128"
+	QR      = "qr"
+)
+
+// ErrSymbology is returned for an unsupported symbology
+var ErrSymbology = errors.New("label: unsupported symbology")
+
+// Label sheet layout in millimetres for A4 sheets of 3 x 8 labels
+const (
+	columns      = 3
+	rows         = 8
+	labelWidth   = 70.0
+	labelHeight  = 37.0
+	sheetTop     = 0.5
+	labelPadding = 3.0
+)
+
+// payload is the text encoded in a QR label
+func payload(u models.LabelUnit) string {
+	return strings.Join([]string{u.PrimaryID, u.SecondaryID, u.Model}, "|")
+}
+
+// code is one code on a label with the text printed under it
+type code struct {
+	caption string
+	img     image.Image
+}
+
+// codes returns the codes printed on a unit label. Code128 labels carry a
+// barcode for each of the primary and secondary numbers so either can be
+// scanned, leaving out a secondary number the unit does not have. QR labels
+// carry one code holding both numbers and the model.
+func codes(u models.LabelUnit, symbology string) ([]code, error) {
+	switch symbology {
+	case Code128:
+		var cs []code
+		for _, s := range []string{u.PrimaryID, u.SecondaryID} {
+			if s == "" {
+				continue
+			}
+			bc, err := code128.Encode(s)
+			if err != nil {
+				return nil, err
+			}
+			scaled, err := barcode.Scale(bc, bc.Bounds().Dx()*3, 80)
+			if err != nil {
+				return nil, err
+			}
+			cs = append(cs, code{s, gray(scaled)})
+		}
+		return cs, nil
+	case QR:
+		bc, err := qr.Encode(payload(u), qr.M, qr.Auto)
+		if err != nil {
+			return nil, err
+		}
+		scaled, err := barcode.Scale(bc, 240, 240)
+		if err != nil {
+			return nil, err
+		}
+		return []code{{"", gray(scaled)}}, nil
+	}
+	return nil, ErrSymbology
+}
+
+// gray converts a code to 8-bit grayscale as gofpdf cannot read 16-bit PNGs
+func gray(img image.Image) image.Image {
+	g := image.NewGray(img.Bounds())
+	draw.Draw(g, g.Bounds(), img, img.Bounds().Min, draw.Src)
+	return g
+}
+
+// PNG writes a unit label as one image: the model followed by the codes
+// stacked with their numbers printed under them
+func PNG(w io.Writer, u models.LabelUnit, symbology string) error {
+	cs, err := codes(u, symbology)
+	if err != nil {
+		return err
+	}
+
+	const margin = 20
+	face := basicfont.Face7x13
+	line := face.Metrics().Height.Ceil()
+
+	captions := []string{u.Model}
+	if symbology == QR {
+		captions = append(captions, u.PrimaryID, u.SecondaryID)
+	}
+
+	width := 0
+	height := margin + len(captions)*line + margin
+	for _, c := range cs {
+		width = maxInt(width, c.img.Bounds().Dx())
+		height += c.img.Bounds().Dy() + margin
+		if c.caption != "" {
+			width = maxInt(width, font.MeasureString(face, c.caption).Ceil())
+			height += line
+		}
+	}
+	for _, text := range captions {
+		width = maxInt(width, font.MeasureString(face, text).Ceil())
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width+2*margin, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+
+	d := &font.Drawer{Dst: canvas, Src: image.Black, Face: face}
+	text := func(s string, y int) {
+		d.Dot = fixed.P(margin, y+face.Metrics().Ascent.Ceil())
+		d.DrawString(s)
+	}
+
+	y := margin
+	for _, caption := range captions {
+		text(caption, y)
+		y += line
+	}
+	y += margin
+	for _, c := range cs {
+		draw.Draw(canvas, image.Rect(margin, y, margin+c.img.Bounds().Dx(), y+c.img.Bounds().Dy()), c.img, c.img.Bounds().Min, draw.Src)
+		y += c.img.Bounds().Dy()
+		if c.caption != "" {
+			text(c.caption, y)
+			y += line
+		}
+		y += margin
+	}
+
+	return png.Encode(w, canvas)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// PDF writes printable A4 label sheets for the units
+func PDF(w io.Writer, units []models.LabelUnit, symbology string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetFont("Helvetica", "", 8)
+
+	for i, u := range units {
+		if i%(columns*rows) == 0 {
+			pdf.AddPage()
+		}
+
+		cs, err := codes(u, symbology)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(cs))
+		for j, c := range cs {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, c.img); err != nil {
+				return err
+			}
+			names[j] = fmt.Sprintf("%d-%d", i, j)
+			pdf.RegisterImageOptionsReader(names[j], gofpdf.ImageOptions{ImageType: "PNG"}, &buf)
+		}
+
+		pos := i % (columns * rows)
+		x := float64(pos%columns)*labelWidth + labelPadding
+		y := sheetTop + float64(pos/columns)*labelHeight + labelPadding
+		inner := labelWidth - 2*labelPadding
+
+		switch symbology {
+		case Code128:
+			pdf.SetXY(x, y)
+			pdf.CellFormat(inner, 4, u.Model, "", 0, "L", false, 0, "")
+			for j, c := range cs {
+				top := y + 4 + float64(j)*13
+				pdf.ImageOptions(names[j], x, top, inner, 9, false, gofpdf.ImageOptions{}, 0, "")
+				pdf.SetXY(x, top+9)
+				pdf.CellFormat(inner, 3, c.caption, "", 0, "C", false, 0, "")
+			}
+		case QR:
+			side := labelHeight - 2*labelPadding
+			pdf.ImageOptions(names[0], x, y, side, side, false, gofpdf.ImageOptions{}, 0, "")
+			for k, text := range []string{u.Model, u.PrimaryID, u.SecondaryID} {
+				pdf.SetXY(x+side+2, y+4+float64(k)*6)
+				pdf.CellFormat(inner-side-2, 4, text, "", 0, "L", false, 0, "")
+			}
+		}
+	}
+
+	return pdf.Output(w)
+}
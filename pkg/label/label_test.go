@@ -0,0 +1,130 @@
+package label
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/ssrdive/basara/pkg/models"
+)
+
+func TestCodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		unit      models.LabelUnit
+		symbology string
+		want      []string
+	}{
+		{"code128 both numbers", models.LabelUnit{PrimaryID: "ENG123", SecondaryID: "CH456", Model: "CD 70"}, Code128, []string{"ENG123", "CH456"}},
+		{"code128 without secondary", models.LabelUnit{PrimaryID: "ENG123", Model: "CD 70"}, Code128, []string{"ENG123"}},
+		{"qr", models.LabelUnit{PrimaryID: "ENG123", SecondaryID: "CH456", Model: "CD 70"}, QR, []string{""}},
+		{"qr without secondary", models.LabelUnit{PrimaryID: "ENG123", Model: "CD 70"}, QR, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := codes(tt.unit, tt.symbology)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cs) != len(tt.want) {
+				t.Fatalf("got %d codes, want %d", len(cs), len(tt.want))
+			}
+			for i, c := range cs {
+				if c.caption != tt.want[i] {
+					t.Errorf("code %d caption = %q, want %q", i, c.caption, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCodesSymbology(t *testing.T) {
+	_, err := codes(models.LabelUnit{PrimaryID: "ENG123"}, "ean13")
+	if err != ErrSymbology {
+		t.Errorf("got %v, want ErrSymbology", err)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	got := payload(models.LabelUnit{PrimaryID: "ENG123", SecondaryID: "CH456", Model: "CD 70"})
+	if want := "ENG123|CH456|CD 70"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// inked reports whether any pixel in the rows [top, bottom) is not white
+func inked(img image.Image, top, bottom int) bool {
+	b := img.Bounds()
+	for y := top; y < bottom; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y != 0xff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestPNG(t *testing.T) {
+	for _, symbology := range []string{Code128, QR} {
+		for _, u := range []models.LabelUnit{
+			{PrimaryID: "ENG123", SecondaryID: "CH456", Model: "CD 70"},
+			{PrimaryID: "ENG123", Model: "CD 70"},
+		} {
+			var buf bytes.Buffer
+			if err := PNG(&buf, u, symbology); err != nil {
+				t.Fatalf("%s %+v: %v", symbology, u, err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("%s %+v: %v", symbology, u, err)
+			}
+			// The model is printed in the first line under the top margin
+			if !inked(img, 20, 33) {
+				t.Errorf("%s %+v: model text not drawn", symbology, u)
+			}
+		}
+	}
+}
+
+func TestPNGModelOnlyLine(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PNG(&buf, models.LabelUnit{PrimaryID: "ENG123"}, Code128); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inked(img, 20, 33) {
+		t.Error("text drawn for an empty model")
+	}
+}
+
+func TestPDF(t *testing.T) {
+	units := make([]models.LabelUnit, columns*rows+1)
+	for i := range units {
+		units[i] = models.LabelUnit{PrimaryID: "ENG123", Model: "CD 70"}
+	}
+	units[0].SecondaryID = "CH456"
+
+	for _, symbology := range []string{Code128, QR} {
+		var buf bytes.Buffer
+		if err := PDF(&buf, units, symbology); err != nil {
+			t.Fatalf("%s: %v", symbology, err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) {
+			t.Errorf("%s: output is not a PDF", symbology)
+		}
+		if n := bytes.Count(buf.Bytes(), []byte("/Type /Page\n")); n != 2 {
+			t.Errorf("%s: got %d pages, want 2", symbology, n)
+		}
+	}
+
+	if err := PDF(&bytes.Buffer{}, units, "ean13"); err != ErrSymbology {
+		t.Errorf("got %v, want ErrSymbology", err)
+	}
+}
@@ -2,11 +2,52 @@ package models
 
 import (
 	"errors"
+	"net/url"
 	"time"
 )
 
 var ErrNoRecord = errors.New("models: no matching record found")
 
+var ErrInvalidUnits = errors.New("models: units not available in warehouse")
+
+var ErrInvalidGoods = errors.New("models: malformed goods or prices")
+
+var ErrReserved = errors.New("models: units reserved by another user")
+
+var ErrInvalidExpiry = errors.New("models: reservation expiry is not a future date")
+
+var ErrNotOwner = errors.New("models: record belongs to another user")
+
+var ErrInvalidPeriod = errors.New("models: invalid report period")
+
+var ErrInsufficientStock = errors.New("models: insufficient quantity in stock")
+
+var ErrInvalidCost = errors.New("models: invalid cost")
+
+var ErrPurchaseOrderExceeded = errors.New("models: received quantity exceeds purchase order")
+
+var ErrInvalidPurchaseOrder = errors.New("models: purchase order lines need a model, a positive quantity and a price")
+
+var ErrPurchaseOrderMismatch = errors.New("models: goods-in does not match purchase order")
+
+var ErrDocumentTypeNotAllowed = errors.New("models: document type not allowed for this transaction")
+
+var ErrDocumentTypeInUse = errors.New("models: document type in use")
+
+var ErrNotPending = errors.New("models: document is not pending approval")
+
+var ErrNotApprover = errors.New("models: user may not decide on this document")
+
+var ErrDraftClosed = errors.New("models: draft already posted or discarded")
+
+var ErrInvalidDraft = errors.New("models: draft header does not name a valid warehouse or purchase order")
+
+var ErrInvalidDate = errors.New("models: invalid document date")
+
+var ErrPeriodLocked = errors.New("models: document date falls in a locked period")
+
+var ErrPeriodUnsettled = errors.New("models: period has pending approvals or open drafts")
+
 type UserResponse struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
@@ -59,6 +100,11 @@ type ItemDetails struct {
 	Price            float64 `json:"price"`
 }
 
+type Item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
 type AllWarehouseItem struct {
 	ID            int    `json:"id"`
 	WarehouseType string `json:"warehouse_type"`
@@ -81,9 +127,22 @@ type GoodsMovement struct {
 	Price           string `json:"price"`
 }
 
-type SecNumberModel struct {
-	SecondaryNumber string `json:"secondaryNumber"`
-	Model           string `json:"model"`
+type SaleGoods struct {
+	PrimaryNumber string `json:"primaryNumber"`
+	Price         string `json:"price"`
+}
+
+type LookupItem struct {
+	PrimaryID   string `json:"primary_id"`
+	SecondaryID string `json:"secondary_id"`
+	Model       string `json:"model"`
+	WarehouseID int    `json:"warehouse_id"`
+	Warehouse   string `json:"warehouse"`
+}
+
+type LookupResult struct {
+	Found   []LookupItem `json:"found"`
+	Missing []string     `json:"missing"`
 }
 
 type ValidTransfer struct {
@@ -100,20 +159,44 @@ type WarehouseStockItem struct {
 	PrimaryID            string `json:"primary_id"`
 	SecondaryID          string `json:"secondary_id"`
 	InStockFor           int    `json:"in_stock_for"`
+	TotalAge             int    `json:"total_age"`
 	Price                int    `json:"price"`
 	Model                string `json:"model"`
 	Date                 string `json:"date"`
 	DeliveryDocumentType string `json:"delivery_document_type"`
+	ReservedUntil        string `json:"reserved_until"`
+}
+
+type SearchFilter struct {
+	Search          string
+	Model           string
+	Warehouse       string
+	PrimaryPrefix   string
+	SecondaryPrefix string
+	MinPrice        string
+	MaxPrice        string
+	MinAge          string
+	MaxAge          string
+	DocumentType    string
+	IncludeHistory  bool
+	Sort            string
+	Page            int
+	Size            int
 }
 
 type SearchResultItem struct {
-	DocumentID  int    `json:"document_id"`
-	Model       string `json:"model"`
-	Warehouse   string `json:"warehouse"`
-	PrimaryID   string `json:"primary_id"`
-	SecondaryID string `json:"secondary_id"`
-	Price       int    `json:"price"`
-	WarehouseID int    `json:"warehouse_id"`
+	DocumentID    int    `json:"document_id"`
+	Model         string `json:"model"`
+	Warehouse     string `json:"warehouse"`
+	PrimaryID     string `json:"primary_id"`
+	SecondaryID   string `json:"secondary_id"`
+	Price         int    `json:"price"`
+	WarehouseID   int    `json:"warehouse_id"`
+	Status        string `json:"status"`
+	Customer      string `json:"customer"`
+	ReservedUntil string `json:"reserved_until"`
+	InStockFor    int    `json:"in_stock_for"`
+	TotalAge      int    `json:"total_age"`
 }
 
 type AgeWiseItem struct {
@@ -121,6 +204,7 @@ type AgeWiseItem struct {
 	PrimaryID            string `json:"primary_id"`
 	SecondaryID          string `json:"secondary_id"`
 	InStockFor           int    `json:"in_stock_for"`
+	TotalAge             int    `json:"total_age"`
 	Price                int    `json:"price"`
 	Model                string `json:"model"`
 	Date                 string `json:"date"`
@@ -143,12 +227,14 @@ type HistoryItem struct {
 
 type DocsItem struct {
 	DocumentID           int    `json:"document_id"`
+	Number               string `json:"number"`
 	DeliveryDocumentType string `json:"delivery_document_type"`
 	Date                 string `json:"date"`
 	ToWarehouseID        string `json:"to_warehouse_id"`
 	ToWarehouse          string `json:"to_warehouse"`
 	FromWarehouseID      string `json:"from_warehouse_id"`
 	FromWarehouse        string `json:"from_warehouse"`
+	Status               string `json:"status"`
 }
 
 type StockByModel struct {
@@ -161,3 +247,429 @@ type AllUserItem struct {
 	Name     string `json:"name"`
 	Type     string `json:"type"`
 }
+
+type Sale struct {
+	DocumentID      int        `json:"document_id"`
+	Date            string     `json:"date"`
+	WarehouseID     int        `json:"warehouse_id"`
+	Warehouse       string     `json:"warehouse"`
+	InvoiceNumber   string     `json:"invoice_number"`
+	CustomerID      int        `json:"customer_id"`
+	CustomerName    string     `json:"customer_name"`
+	CustomerNIC     string     `json:"customer_nic"`
+	CustomerContact string     `json:"customer_contact"`
+	CustomerAddress string     `json:"customer_address"`
+	FinanceCompany  string     `json:"finance_company"`
+	Units           []SoldUnit `json:"units"`
+}
+
+type SoldUnit struct {
+	PrimaryID   string `json:"primary_id"`
+	SecondaryID string `json:"secondary_id"`
+	Model       string `json:"model"`
+	Price       int    `json:"price"`
+}
+
+type Customer struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	NIC     string `json:"nic"`
+	Contact string `json:"contact"`
+	Address string `json:"address"`
+}
+
+type CustomerPurchase struct {
+	DocumentID    int    `json:"document_id"`
+	Date          string `json:"date"`
+	InvoiceNumber string `json:"invoice_number"`
+	Warehouse     string `json:"warehouse"`
+	PrimaryID     string `json:"primary_id"`
+	SecondaryID   string `json:"secondary_id"`
+	Model         string `json:"model"`
+	Price         int    `json:"price"`
+}
+
+type Supplier struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Contact string `json:"contact"`
+	Address string `json:"address"`
+}
+
+type PurchaseOrderGoods struct {
+	Model    string `json:"model"`
+	Quantity int    `json:"quantity"`
+	Price    string `json:"price"`
+}
+
+type PurchaseOrderLine struct {
+	ID       int
+	ModelID  string
+	Quantity int
+	Received int
+	Price    string
+}
+
+type PurchaseOrder struct {
+	ID          int                 `json:"id"`
+	Date        string              `json:"date"`
+	SupplierID  int                 `json:"supplier_id"`
+	Supplier    string              `json:"supplier"`
+	WarehouseID int                 `json:"warehouse_id"`
+	Warehouse   string              `json:"warehouse"`
+	Items       []PurchaseOrderItem `json:"items"`
+}
+
+type PurchaseOrderItem struct {
+	ModelID     int    `json:"model_id"`
+	Model       string `json:"model"`
+	Quantity    int    `json:"quantity"`
+	Received    int    `json:"received"`
+	Outstanding int    `json:"outstanding"`
+	Price       int    `json:"price"`
+}
+
+type OutstandingPurchaseItem struct {
+	PurchaseOrderID int    `json:"purchase_order_id"`
+	Date            string `json:"date"`
+	Supplier        string `json:"supplier"`
+	Warehouse       string `json:"warehouse"`
+	Model           string `json:"model"`
+	Quantity        int    `json:"quantity"`
+	Received        int    `json:"received"`
+	Outstanding     int    `json:"outstanding"`
+}
+
+type Reservation struct {
+	ID          int    `json:"id"`
+	PrimaryID   string `json:"primary_id"`
+	SecondaryID string `json:"secondary_id"`
+	Model       string `json:"model"`
+	Warehouse   string `json:"warehouse"`
+	CustomerID  int    `json:"customer_id"`
+	Customer    string `json:"customer"`
+	ReservedFor string `json:"reserved_for"`
+	ExpiresAt   string `json:"expires_at"`
+	CreatedBy   string `json:"created_by"`
+}
+
+type Valuation struct {
+	AsAt             string                      `json:"as_at"`
+	ByWarehouse      []ValuationByWarehouse      `json:"by_warehouse"`
+	ByModel          []ValuationByModel          `json:"by_model"`
+	ByWarehouseModel []ValuationByWarehouseModel `json:"by_warehouse_model"`
+}
+
+type ValuationByWarehouse struct {
+	WarehouseID int     `json:"warehouse_id"`
+	Warehouse   string  `json:"warehouse"`
+	Count       int     `json:"count"`
+	Total       float64 `json:"total"`
+	Average     float64 `json:"average"`
+}
+
+type ValuationByModel struct {
+	ModelID int     `json:"model_id"`
+	Model   string  `json:"model"`
+	Count   int     `json:"count"`
+	Total   float64 `json:"total"`
+	Average float64 `json:"average"`
+}
+
+type ValuationByWarehouseModel struct {
+	WarehouseID int     `json:"warehouse_id"`
+	Warehouse   string  `json:"warehouse"`
+	ModelID     int     `json:"model_id"`
+	Model       string  `json:"model"`
+	Count       int     `json:"count"`
+	Total       float64 `json:"total"`
+	Average     float64 `json:"average"`
+}
+
+type AsAtStockItem struct {
+	DocumentID  int    `json:"document_id"`
+	PrimaryID   string `json:"primary_id"`
+	SecondaryID string `json:"secondary_id"`
+	Model       string `json:"model"`
+	Price       int    `json:"price"`
+	WarehouseID int    `json:"warehouse_id"`
+	Warehouse   string `json:"warehouse"`
+	DateIn      string `json:"date_in"`
+}
+
+type AgeingUnit struct {
+	WarehouseID int
+	Warehouse   string
+	ModelID     int
+	Model       string
+	Age         int
+	TotalAge    int
+	Price       float64
+}
+
+type Ageing struct {
+	Buckets []string     `json:"buckets"`
+	Items   []AgeingItem `json:"items"`
+}
+
+type AgeingItem struct {
+	WarehouseID int     `json:"warehouse_id"`
+	Warehouse   string  `json:"warehouse"`
+	ModelID     int     `json:"model_id"`
+	Model       string  `json:"model"`
+	Bucket      string  `json:"bucket"`
+	Count       int     `json:"count"`
+	Value       float64 `json:"value"`
+}
+
+type MovementEvent struct {
+	Period      string
+	WarehouseID int
+	Warehouse   string
+	ModelID     int
+	Model       string
+	Kind        string
+	Count       int
+}
+
+type MovementSummaryItem struct {
+	Period      string `json:"period"`
+	WarehouseID int    `json:"warehouse_id"`
+	Warehouse   string `json:"warehouse"`
+	ModelID     int    `json:"model_id"`
+	Model       string `json:"model"`
+	Opening     int    `json:"opening"`
+	Inflow      int    `json:"inflow"`
+	Outflow     int    `json:"outflow"`
+	TransferIn  int    `json:"transfer_in"`
+	TransferOut int    `json:"transfer_out"`
+	Closing     int    `json:"closing"`
+}
+
+type ReorderLevel struct {
+	ID          int    `json:"id"`
+	WarehouseID int    `json:"warehouse_id"`
+	Warehouse   string `json:"warehouse"`
+	ModelID     int    `json:"model_id"`
+	Model       string `json:"model"`
+	MinQuantity int    `json:"min_quantity"`
+	MaxQuantity int    `json:"max_quantity"`
+}
+
+type ReorderSuggestion struct {
+	WarehouseID int    `json:"warehouse_id"`
+	Warehouse   string `json:"warehouse"`
+	ModelID     int    `json:"model_id"`
+	Model       string `json:"model"`
+	MinQuantity int    `json:"min_quantity"`
+	MaxQuantity int    `json:"max_quantity"`
+	OnHand      int    `json:"on_hand"`
+	Suggested   int    `json:"suggested"`
+}
+
+type LabelUnit struct {
+	PrimaryID   string `json:"primary_id"`
+	SecondaryID string `json:"secondary_id"`
+	Model       string `json:"model"`
+}
+
+type UnitDetail struct {
+	PrimaryID   string           `json:"primary_id"`
+	SecondaryID string           `json:"secondary_id"`
+	ModelID     int              `json:"model_id"`
+	Model       string           `json:"model"`
+	Status      string           `json:"status"`
+	Current     *UnitLocation    `json:"current"`
+	Reservation *Reservation     `json:"reservation"`
+	Sale        *UnitSale        `json:"sale"`
+	Attachments []UnitAttachment `json:"attachments"`
+	Timeline    []TimelineItem   `json:"timeline"`
+}
+
+type UnitIdentity struct {
+	PrimaryID   string
+	SecondaryID string
+	ModelID     int
+	Model       string
+}
+
+type UnitLocation struct {
+	DocumentID   int    `json:"document_id"`
+	DocumentType string `json:"document_type"`
+	WarehouseID  int    `json:"warehouse_id"`
+	Warehouse    string `json:"warehouse"`
+	Price        int    `json:"price"`
+	Date         string `json:"date"`
+	InStockFor   int    `json:"in_stock_for"`
+}
+
+type UnitSale struct {
+	DocumentID    int    `json:"document_id"`
+	Date          string `json:"date"`
+	InvoiceNumber string `json:"invoice_number"`
+	CustomerID    int    `json:"customer_id"`
+	Customer      string `json:"customer"`
+	Price         int    `json:"price"`
+}
+
+type UnitAttachment struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+type TimelineItem struct {
+	DocumentID   int    `json:"document_id"`
+	DocumentType string `json:"document_type"`
+	WarehouseID  int    `json:"warehouse_id"`
+	Warehouse    string `json:"warehouse"`
+	DateIn       string `json:"date_in"`
+	DateOut      string `json:"date_out"`
+	Days         int    `json:"days"`
+	Price        int    `json:"price"`
+}
+
+type ItemQuantity struct {
+	ItemID   string `json:"item_id"`
+	Quantity int    `json:"quantity"`
+	Price    string `json:"price"`
+}
+
+type ItemStockItem struct {
+	ItemID   int    `json:"item_id"`
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+type ItemLedgerEntry struct {
+	DocumentID   int    `json:"document_id"`
+	DocumentType string `json:"document_type"`
+	Date         string `json:"date"`
+	WarehouseID  int    `json:"warehouse_id"`
+	Warehouse    string `json:"warehouse"`
+	Quantity     int    `json:"quantity"`
+	Price        string `json:"price"`
+}
+
+type ModelPrice struct {
+	ID            int     `json:"id"`
+	ModelID       int     `json:"model_id"`
+	Model         string  `json:"model"`
+	Cost          float64 `json:"cost"`
+	Dealer        float64 `json:"dealer"`
+	Retail        float64 `json:"retail"`
+	EffectiveFrom string  `json:"effective_from"`
+}
+
+type UnitCost struct {
+	PrimaryID    string
+	Currency     string
+	ExchangeRate float64
+	ForeignPrice float64
+	LocalPrice   float64
+	Freight      float64
+	Duty         float64
+	Clearing     float64
+	LandedCost   float64
+}
+
+type DocumentUnitCost struct {
+	PrimaryID string
+	Price     float64
+}
+
+type DocumentCost struct {
+	ID        int     `json:"id"`
+	CostType  string  `json:"cost_type"`
+	Amount    float64 `json:"amount"`
+	Method    string  `json:"method"`
+	CreatedBy string  `json:"created_by"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type DocumentSeries struct {
+	ID             int    `json:"id"`
+	DocumentTypeID int    `json:"document_type_id"`
+	DocumentType   string `json:"document_type"`
+	WarehouseID    int    `json:"warehouse_id"`
+	Warehouse      string `json:"warehouse"`
+	Prefix         string `json:"prefix"`
+	Padding        int    `json:"padding"`
+}
+
+type DocumentType struct {
+	ID                         int    `json:"id"`
+	Name                       string `json:"name"`
+	Inbound                    bool   `json:"inbound"`
+	Transfer                   bool   `json:"transfer"`
+	Outbound                   bool   `json:"outbound"`
+	Adjustment                 bool   `json:"adjustment"`
+	RequiresApproval           bool   `json:"requires_approval"`
+	SourceWarehouseTypeID      int    `json:"source_warehouse_type_id"`
+	SourceWarehouseType        string `json:"source_warehouse_type"`
+	DestinationWarehouseTypeID int    `json:"destination_warehouse_type_id"`
+	DestinationWarehouseType   string `json:"destination_warehouse_type"`
+}
+
+type ApprovalRule struct {
+	ID            int     `json:"id"`
+	DocumentType  string  `json:"document_type"`
+	FromWarehouse string  `json:"from_warehouse"`
+	ToWarehouse   string  `json:"to_warehouse"`
+	MinUnits      int     `json:"min_units"`
+	MinValue      float64 `json:"min_value"`
+	Approver      string  `json:"approver"`
+}
+
+type PendingApproval struct {
+	DocumentID    int    `json:"document_id"`
+	Number        string `json:"number"`
+	DocumentType  string `json:"document_type"`
+	Date          string `json:"date"`
+	FromWarehouse string `json:"from_warehouse"`
+	ToWarehouse   string `json:"to_warehouse"`
+	Units         int    `json:"units"`
+	RequestedBy   string `json:"requested_by"`
+	RequestedAt   string `json:"requested_at"`
+}
+
+type DraftItem struct {
+	Model           string `json:"model"`
+	PrimaryNumber   string `json:"primary_number"`
+	SecondaryNumber string `json:"secondary_number"`
+	Price           string `json:"price"`
+}
+
+type Draft struct {
+	ID         int         `json:"id"`
+	Kind       string      `json:"kind"`
+	Header     url.Values  `json:"header"`
+	Status     string      `json:"status"`
+	DocumentID int         `json:"document_id"`
+	CreatedBy  string      `json:"created_by"`
+	CreatedAt  string      `json:"created_at"`
+	UpdatedAt  string      `json:"updated_at"`
+	Items      []DraftItem `json:"items"`
+}
+
+type DraftSummary struct {
+	ID        int    `json:"id"`
+	Kind      string `json:"kind"`
+	Units     int    `json:"units"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type DocumentLock struct {
+	LockDate string `json:"lock_date"`
+	SetBy    string `json:"set_by"`
+	SetAt    string `json:"set_at"`
+}
+
+type AccountingPeriod struct {
+	Period   string `json:"period"`
+	ClosedBy string `json:"closed_by"`
+	ClosedAt string `json:"closed_at"`
+}
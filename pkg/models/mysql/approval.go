@@ -0,0 +1,282 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// CreateApprovalRule adds a rule holding matching movements for approval.
+// Conditions left empty match any movement.
+func (m *Warehouse) CreateApprovalRule(rparams, oparams []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.FormTable{
+		TableName: "approval_rule",
+		RCols:     rparams,
+		OCols:     oparams,
+		Form:      form,
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// DeleteApprovalRule removes a rule so it no longer holds movements.
+// Approvals it already asked for keep their approver and stay pending.
+func (m *Warehouse) DeleteApprovalRule(id int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	_, err = tx.Exec(queries.DETACH_APPROVAL_RULE, id)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM approval_rule WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		err = models.ErrNoRecord
+		return err
+	}
+
+	return nil
+}
+
+// ApprovalRules returns every approval rule
+func (m *Warehouse) ApprovalRules() ([]models.ApprovalRule, error) {
+	var res []models.ApprovalRule
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ALL_APPROVAL_RULES)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// PendingApprovals returns the movements waiting for the user to approve.
+// Movements without a named approver wait for an admin.
+func (m *Warehouse) PendingApprovals(user string, admin bool) ([]models.PendingApproval, error) {
+	var res []models.PendingApproval
+	err := mysequel.QueryToStructs(&res, m.DB, queries.PENDING_APPROVALS, user, user, admin)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// requestApproval holds a movement for approval when its document type or a
+// rule asks for it, recording the units to move once approved
+func (m *Warehouse) requestApproval(tx *sql.Tx, did int64, documentType, source, destination, user string, res []models.ValidTransfer) (bool, error) {
+	var required bool
+	err := tx.QueryRow(queries.DOCUMENT_TYPE_REQUIRES_APPROVAL, documentType).Scan(&required)
+	if err != nil {
+		return false, err
+	}
+
+	value := 0.0
+	for _, u := range res {
+		price, _ := strconv.ParseFloat(u.Price, 64)
+		value += price
+	}
+
+	ruleID, approver := "", ""
+	err = tx.QueryRow(queries.MATCHING_APPROVAL_RULE, documentType, source, destination, len(res), value).Scan(&ruleID, &approver)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	} else if err != nil {
+		return false, err
+	} else {
+		required = true
+	}
+
+	if !required {
+		return false, nil
+	}
+
+	// Users may not approve their own movements, so one the rule would send
+	// back to its requester waits for an admin instead
+	if approver == user {
+		approver = ""
+	}
+
+	_, err = mysequel.Insert(mysequel.Table{
+		TableName: "document_approval",
+		Columns:   []string{"document_id", "approval_rule_id", "approver", "status", "requested_by", "requested_at"},
+		Vals:      []interface{}{did, ruleID, approver, "pending", user, time.Now().Format("2006-01-02 15:04:05")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, u := range res {
+		_, err = mysequel.Insert(mysequel.Table{
+			TableName: "document_pending_item",
+			Columns:   []string{"document_id", "primary_id"},
+			Vals:      []interface{}{did, u.PrimaryID},
+			Tx:        tx,
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Approve applies a pending movement to the stock. The units must still be
+// in the source warehouse.
+func (m *Warehouse) Approve(id int, user string, admin bool) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	a, err := pendingApproval(tx, id, user, admin)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(queries.DOCUMENT_PENDING_ITEMS, id)
+	if err != nil {
+		return err
+	}
+	var pids []interface{}
+	for rows.Next() {
+		var pid string
+		err = rows.Scan(&pid)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		pids = append(pids, pid)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	res, err := movableUnits(tx, a.source, pids, int64(id))
+	if err != nil {
+		return err
+	}
+
+	err = m.checkReservations(tx, a.requestedBy, "0", a.destination, pids)
+	if err != nil {
+		return err
+	}
+
+	err = checkLegDates(res, a.date)
+	if err != nil {
+		return err
+	}
+
+	err = moveUnits(tx, int64(id), a.date, res)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(queries.DECIDE_APPROVAL, "approved", user, time.Now().Format("2006-01-02 15:04:05"), "", id)
+	return err
+}
+
+// Reject closes a pending movement without moving its units
+func (m *Warehouse) Reject(id int, user, reason string, admin bool) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	_, err = pendingApproval(tx, id, user, admin)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(queries.DECIDE_APPROVAL, "rejected", user, time.Now().Format("2006-01-02 15:04:05"), reason, id)
+	return err
+}
+
+type approval struct {
+	requestedBy, source, destination string
+	date                             time.Time
+}
+
+// pendingApproval locks the approval of a document the user may decide on
+// while its period is still open. The named approver decides, or an admin
+// when there is none, and never the user who asked for the movement.
+func pendingApproval(tx *sql.Tx, id int, user string, admin bool) (approval, error) {
+	var a approval
+	var status, approver string
+	err := tx.QueryRow(queries.APPROVAL_FOR_UPDATE, id).Scan(&status, &approver, &a.requestedBy, &a.source, &a.destination, &a.date)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return approval{}, models.ErrNoRecord
+		}
+		return approval{}, err
+	}
+
+	if status != "pending" {
+		return approval{}, models.ErrNotPending
+	}
+	if a.requestedBy == user || (approver != "" && approver != user) || (approver == "" && !admin) {
+		return approval{}, models.ErrNotApprover
+	}
+
+	err = checkPeriodOpen(tx, a.date)
+	if err != nil {
+		return approval{}, err
+	}
+
+	return a, nil
+}
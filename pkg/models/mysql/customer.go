@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// CustomerModel struct holds methods to query customer table
+type CustomerModel struct {
+	DB *sql.DB
+}
+
+// Create creates a customer
+func (m *CustomerModel) Create(rparams, oparams []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.FormTable{
+		TableName: "customer",
+		RCols:     rparams,
+		OCols:     oparams,
+		Form:      form,
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Get returns a customer
+func (m *CustomerModel) Get(id int) (models.Customer, error) {
+	var c models.Customer
+	err := m.DB.QueryRow(queries.CUSTOMER, id).Scan(&c.ID, &c.Name, &c.NIC, &c.Contact, &c.Address)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Customer{}, models.ErrNoRecord
+		}
+		return models.Customer{}, err
+	}
+
+	return c, nil
+}
+
+// Search returns customers matching name, NIC or contact number
+func (m *CustomerModel) Search(search string) ([]models.Customer, error) {
+	var res []models.Customer
+	err := mysequel.QueryToStructs(&res, m.DB, queries.SEARCH_CUSTOMERS, "%"+search+"%")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Purchases returns the units sold to a customer
+func (m *CustomerModel) Purchases(id int) ([]models.CustomerPurchase, error) {
+	var res []models.CustomerPurchase
+	err := mysequel.QueryToStructs(&res, m.DB, queries.CUSTOMER_PURCHASES, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,204 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// CreateSeries configures the numbering series of a document type issued by
+// a warehouse
+func (m *Warehouse) CreateSeries(rparams, oparams []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.FormTable{
+		TableName: "document_series",
+		RCols:     rparams,
+		OCols:     oparams,
+		Form:      form,
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// AllSeries returns the configured numbering series
+func (m *Warehouse) AllSeries() ([]models.DocumentSeries, error) {
+	var res []models.DocumentSeries
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ALL_DOCUMENT_SERIES)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// SearchDocuments returns documents whose number starts with the given text
+func (m *Warehouse) SearchDocuments(number string) ([]models.DocsItem, error) {
+	var res []models.DocsItem
+	err := mysequel.QueryToStructs(&res, m.DB, queries.SEARCH_DOCUMENTS, prefix(number))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// documentNumber takes the next number of the series of a document type and
+// warehouse for the year of the document. The counter row stays locked until
+// the transaction ends so concurrent documents can't share a number. An empty
+// number is returned when no series is configured.
+func documentNumber(tx *sql.Tx, documentType, warehouseID string, date time.Time) (string, error) {
+	var seriesID, padding int
+	var prefix string
+	err := tx.QueryRow(queries.DOCUMENT_SERIES, documentType, warehouseID).Scan(&seriesID, &prefix, &padding)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(queries.NEXT_DOCUMENT_NUMBER, seriesID, date.Year())
+	if err != nil {
+		return "", err
+	}
+
+	var next int
+	err = tx.QueryRow("SELECT LAST_INSERT_ID()").Scan(&next)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s-%d-%0*d", prefix, date.Year(), padding, next), nil
+}
+
+// documentDate parses the business date of a document. A date given without
+// a time takes the current time of day so documents entered on the same day
+// keep their order. Dates ahead of today or on or before the lock date are
+// refused.
+func documentDate(tx *sql.Tx, value string) (time.Time, error) {
+	now := time.Now()
+	date, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		var day time.Time
+		day, err = time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return time.Time{}, models.ErrInvalidDate
+		}
+		date = time.Date(day.Year(), day.Month(), day.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.Local)
+	}
+
+	if date.Format("2006-01-02") > now.Format("2006-01-02") {
+		return time.Time{}, models.ErrInvalidDate
+	}
+
+	err = checkPeriodOpen(tx, date)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return date, nil
+}
+
+// checkPeriodOpen fails when documents dated on the given day may no longer
+// change, because the day is on or before the lock date or its month or a
+// later one has been closed
+func checkPeriodOpen(tx *sql.Tx, date time.Time) error {
+	var closed int
+	err := tx.QueryRow(queries.PERIOD_CLOSED, date.Format("2006-01")).Scan(&closed)
+	if err != nil {
+		return err
+	}
+	if closed > 0 {
+		return models.ErrPeriodLocked
+	}
+
+	var lock string
+	err = tx.QueryRow(queries.LOCK_DATE).Scan(&lock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if date.Format("2006-01-02") <= lock {
+		return models.ErrPeriodLocked
+	}
+
+	return nil
+}
+
+// checkLegDates fails when a unit would leave before the day it came in
+func checkLegDates(res []models.ValidTransfer, date time.Time) error {
+	for _, u := range res {
+		if date.Format("2006-01-02") < u.Date.Format("2006-01-02") {
+			return models.ErrInvalidDate
+		}
+	}
+	return nil
+}
+
+// SetLockDate stops documents from being dated on or before the given day
+func (m *Warehouse) SetLockDate(date, user string) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	_, err = time.Parse("2006-01-02", date)
+	if err != nil {
+		err = models.ErrInvalidDate
+		return 0, err
+	}
+
+	id, err := mysequel.Insert(mysequel.Table{
+		TableName: "document_lock",
+		Columns:   []string{"lock_date", "set_by", "set_at"},
+		Vals:      []interface{}{date, user, time.Now().Format("2006-01-02 15:04:05")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// LockDate returns the day up to which documents are locked, if any
+func (m *Warehouse) LockDate() (models.DocumentLock, error) {
+	var res []models.DocumentLock
+	err := mysequel.QueryToStructs(&res, m.DB, queries.CURRENT_DOCUMENT_LOCK)
+	if err != nil {
+		return models.DocumentLock{}, err
+	}
+	if len(res) == 0 {
+		return models.DocumentLock{}, nil
+	}
+
+	return res[0], nil
+}
@@ -0,0 +1,207 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// DocumentTypeModel struct holds methods to manage document types
+type DocumentTypeModel struct {
+	DB *sql.DB
+}
+
+// Create adds a document type with its behavior flags and the warehouse
+// types it is restricted to
+func (m *DocumentTypeModel) Create(rparams, oparams []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.FormTable{
+		TableName: "document_type",
+		RCols:     rparams,
+		OCols:     oparams,
+		Form:      form,
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Update changes a document type. Once documents use the type its inbound,
+// transfer, outbound and adjustment flags are fixed, as changing them would
+// change what those documents mean, and ErrDocumentTypeInUse is returned.
+func (m *DocumentTypeModel) Update(id string, cols []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	var flags [4]bool
+	err = tx.QueryRow(queries.DOCUMENT_TYPE_FLAGS_FOR_UPDATE, id).Scan(&flags[0], &flags[1], &flags[2], &flags[3])
+	if errors.Is(err, sql.ErrNoRows) {
+		err = models.ErrNoRecord
+		return 0, err
+	} else if err != nil {
+		return 0, err
+	}
+
+	var used int
+	err = tx.QueryRow("SELECT COUNT(*) FROM document WHERE document_type_id = ?", id).Scan(&used)
+	if err != nil {
+		return 0, err
+	}
+	if used > 0 {
+		for i, col := range []string{"inbound", "transfer", "outbound", "adjustment"} {
+			if v, perr := strconv.ParseBool(form.Get(col)); perr != nil || v != flags[i] {
+				err = models.ErrDocumentTypeInUse
+				return 0, err
+			}
+		}
+	}
+
+	vals := make([]interface{}, len(cols))
+	for i, col := range cols {
+		vals[i] = form.Get(col)
+	}
+
+	n, err := mysequel.Update(mysequel.UpdateTable{
+		Table: mysequel.Table{
+			TableName: "document_type",
+			Columns:   cols,
+			Vals:      vals,
+			Tx:        tx,
+		},
+		WColumns: []string{"id"},
+		WVals:    []string{id},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Delete removes a document type that no document uses yet
+func (m *DocumentTypeModel) Delete(id int) error {
+	var used int
+	err := m.DB.QueryRow("SELECT COUNT(*) FROM document WHERE document_type_id = ?", id).Scan(&used)
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return models.ErrDocumentTypeInUse
+	}
+
+	res, err := m.DB.Exec("DELETE FROM document_type WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// All returns every document type
+func (m *DocumentTypeModel) All() ([]models.DocumentType, error) {
+	var res []models.DocumentType
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ALL_DOCUMENT_TYPES)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Get returns a document type
+func (m *DocumentTypeModel) Get(id int) (models.DocumentType, error) {
+	var res []models.DocumentType
+	err := mysequel.QueryToStructs(&res, m.DB, queries.DOCUMENT_TYPE, id)
+	if err != nil {
+		return models.DocumentType{}, err
+	}
+	if len(res) == 0 {
+		return models.DocumentType{}, models.ErrNoRecord
+	}
+
+	return res[0], nil
+}
+
+// documentTypeAllows checks that a document type may be used for an inbound,
+// transfer or outbound transaction between the given warehouses. Adjustment
+// types may write stock on or off in place of an inbound or outbound type.
+// Warehouse types are only checked for the warehouses given.
+func documentTypeAllows(tx *sql.Tx, documentType, kind, source, destination string) error {
+	var inbound, transfer, outbound, adjustment bool
+	var sourceType, destinationType int
+	err := tx.QueryRow(queries.DOCUMENT_TYPE_RULES, documentType).Scan(&inbound, &transfer, &outbound, &adjustment, &sourceType, &destinationType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrDocumentTypeNotAllowed
+	} else if err != nil {
+		return err
+	}
+
+	allowed := false
+	switch kind {
+	case "inbound":
+		allowed = inbound || adjustment
+	case "transfer":
+		allowed = transfer
+	case "outbound":
+		allowed = outbound || adjustment
+	}
+	if !allowed {
+		return models.ErrDocumentTypeNotAllowed
+	}
+
+	for _, w := range []struct {
+		id       string
+		required int
+	}{{source, sourceType}, {destination, destinationType}} {
+		if w.id == "" || w.required == 0 {
+			continue
+		}
+
+		var warehouseType int
+		err = tx.QueryRow(queries.WAREHOUSE_TYPE_OF, w.id).Scan(&warehouseType)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if warehouseType != w.required {
+			return models.ErrDocumentTypeNotAllowed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,384 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// CreateDraft starts a goods-in or movement draft keeping the header fields
+// of the form to post it with later. The header is checked as posting would
+// check it, so a draft is not filled only to be refused at the end.
+func (m *Warehouse) CreateDraft(kind string, form url.Values, user string) (int64, error) {
+	header := url.Values{}
+	for k, v := range form {
+		if k != "kind" && k != "goods" {
+			header[k] = v
+		}
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	err = checkDraftHeader(tx, kind, header)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	id, err := mysequel.Insert(mysequel.Table{
+		TableName: "draft",
+		Columns:   []string{"kind", "header", "status", "created_by", "created_at", "updated_at"},
+		Vals:      []interface{}{kind, header.Encode(), "open", user, now, now},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// AddDraftItem validates a unit against the stock as it is now and adds it to
+// an open draft
+func (m *Warehouse) AddDraftItem(id int, item models.DraftItem, user string) (models.DraftItem, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return models.DraftItem{}, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	d, err := openDraft(tx, id, user)
+	if err != nil {
+		return models.DraftItem{}, err
+	}
+
+	var n int
+	err = tx.QueryRow(queries.DRAFT_HAS_UNIT, id, item.PrimaryNumber).Scan(&n)
+	if err != nil {
+		return models.DraftItem{}, err
+	}
+	if n > 0 {
+		err = models.ErrInvalidUnits
+		return models.DraftItem{}, err
+	}
+
+	switch d.kind {
+	case "goods_in":
+		err = tx.QueryRow(queries.UNIT_IN_STOCK, item.PrimaryNumber).Scan(&n)
+		if err != nil {
+			return models.DraftItem{}, err
+		}
+		var drafted int
+		err = tx.QueryRow(queries.OPEN_GOODS_IN_DRAFTS_WITH_UNIT, item.PrimaryNumber).Scan(&drafted)
+		if err != nil {
+			return models.DraftItem{}, err
+		}
+		if n > 0 || drafted > 0 || item.Model == "" {
+			err = models.ErrInvalidUnits
+			return models.DraftItem{}, err
+		}
+	case "movement":
+		var res []models.ValidTransfer
+		res, err = movableUnits(tx, d.header.Get("warehouse_id"), []interface{}{item.PrimaryNumber}, 0)
+		if err != nil {
+			return models.DraftItem{}, err
+		}
+		err = m.checkReservations(tx, user, "0", d.header.Get("from_warehouse_id"), []interface{}{item.PrimaryNumber})
+		if err != nil {
+			return models.DraftItem{}, err
+		}
+		item.Model, item.SecondaryNumber, item.Price = res[0].ModelID, res[0].SecondaryID, res[0].Price
+	}
+
+	_, err = mysequel.Insert(mysequel.Table{
+		TableName: "draft_item",
+		Columns:   []string{"draft_id", "model_id", "primary_id", "secondary_id", "price"},
+		Vals:      []interface{}{id, item.Model, item.PrimaryNumber, item.SecondaryNumber, item.Price},
+		Tx:        tx,
+	})
+	if err != nil {
+		return models.DraftItem{}, err
+	}
+
+	_, err = tx.Exec("UPDATE draft SET updated_at = ? WHERE id = ?", time.Now().Format("2006-01-02 15:04:05"), id)
+	if err != nil {
+		return models.DraftItem{}, err
+	}
+
+	return item, nil
+}
+
+// RemoveDraftItem takes a unit off an open draft
+func (m *Warehouse) RemoveDraftItem(id int, primaryID, user string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	_, err = openDraft(tx, id, user)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM draft_item WHERE draft_id = ? AND primary_id = ?", id, primaryID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		err = models.ErrNoRecord
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE draft SET updated_at = ? WHERE id = ?", time.Now().Format("2006-01-02 15:04:05"), id)
+	return err
+}
+
+// Draft returns a draft of the user with its header fields and units
+func (m *Warehouse) Draft(id int, user string) (models.Draft, error) {
+	var d models.Draft
+	var header string
+	err := m.DB.QueryRow(queries.DRAFT, id).Scan(&d.ID, &d.Kind, &header, &d.Status, &d.DocumentID, &d.CreatedBy, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Draft{}, models.ErrNoRecord
+		}
+		return models.Draft{}, err
+	}
+	if d.CreatedBy != user {
+		return models.Draft{}, models.ErrNotOwner
+	}
+
+	d.Header, err = url.ParseQuery(header)
+	if err != nil {
+		return models.Draft{}, err
+	}
+
+	err = mysequel.QueryToStructs(&d.Items, m.DB, queries.DRAFT_ITEMS, id)
+	if err != nil {
+		return models.Draft{}, err
+	}
+
+	return d, nil
+}
+
+// OpenDrafts returns the drafts of a user still to be posted
+func (m *Warehouse) OpenDrafts(user string) ([]models.DraftSummary, error) {
+	var res []models.DraftSummary
+	err := mysequel.QueryToStructs(&res, m.DB, queries.OPEN_DRAFTS, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// PostDraft records the goods-in or movement of a draft as if its units had
+// been submitted at once, closing the draft in the same transaction
+func (m *Warehouse) PostDraft(id int, user string) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	d, err := openDraft(tx, id, user)
+	if err != nil {
+		return 0, err
+	}
+
+	var items []models.DraftItem
+	err = mysequel.QueryToStructs(&items, tx, queries.DRAFT_ITEMS, id)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		err = models.ErrInvalidUnits
+		return 0, err
+	}
+
+	form := d.header
+	var did int64
+	switch d.kind {
+	case "goods_in":
+		goods := make([]models.GoodsInItem, len(items))
+		for i, item := range items {
+			goods[i] = models.GoodsInItem{Model: item.Model, PrimaryNumber: item.PrimaryNumber, SecondaryNumber: item.SecondaryNumber, Price: item.Price}
+		}
+		err = setGoods(form, goods)
+		if err != nil {
+			return 0, err
+		}
+		did, err = m.goodsIn(tx, form)
+	case "movement":
+		goods := make([]models.GoodsMovement, len(items))
+		for i, item := range items {
+			goods[i] = models.GoodsMovement{Model: item.Model, PrimaryNumber: item.PrimaryNumber, SecondaryNumber: item.SecondaryNumber, Price: item.Price}
+		}
+		err = setGoods(form, goods)
+		if err != nil {
+			return 0, err
+		}
+		did, err = m.movement(tx, form, user)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("UPDATE draft SET status = 'posted', document_id = ?, updated_at = ? WHERE id = ?", did, time.Now().Format("2006-01-02 15:04:05"), id)
+	if err != nil {
+		return 0, err
+	}
+
+	return did, nil
+}
+
+// DiscardDraft closes an open draft without posting it
+func (m *Warehouse) DiscardDraft(id int, user string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	_, err = openDraft(tx, id, user)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE draft SET status = 'discarded', updated_at = ? WHERE id = ?", time.Now().Format("2006-01-02 15:04:05"), id)
+	return err
+}
+
+type draft struct {
+	kind   string
+	header url.Values
+}
+
+// openDraft locks a draft of the user that is still open
+func openDraft(tx *sql.Tx, id int, user string) (draft, error) {
+	var d draft
+	var header, status, createdBy string
+	err := tx.QueryRow(queries.DRAFT_FOR_UPDATE, id).Scan(&d.kind, &header, &status, &createdBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return draft{}, models.ErrNoRecord
+		}
+		return draft{}, err
+	}
+	if createdBy != user {
+		return draft{}, models.ErrNotOwner
+	}
+	if status != "open" {
+		return draft{}, models.ErrDraftClosed
+	}
+
+	d.header, err = url.ParseQuery(header)
+	if err != nil {
+		return draft{}, err
+	}
+
+	return d, nil
+}
+
+// checkDraftHeader checks the document type, warehouses, date and, for a
+// goods-in, the purchase order of a draft header
+func checkDraftHeader(tx *sql.Tx, kind string, header url.Values) error {
+	transaction, source, destination := "inbound", header.Get("from_warehouse_id"), header.Get("warehouse_id")
+	if kind == "movement" {
+		transaction, source, destination = "transfer", header.Get("warehouse_id"), header.Get("from_warehouse_id")
+	}
+
+	for _, w := range []string{source, destination} {
+		if w == "" {
+			continue
+		}
+		var n int
+		err := tx.QueryRow(queries.WAREHOUSE_EXISTS, w).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return models.ErrInvalidDraft
+		}
+	}
+
+	err := documentTypeAllows(tx, header.Get("document_type"), transaction, source, destination)
+	if err != nil {
+		return err
+	}
+
+	_, err = documentDate(tx, header.Get("date"))
+	if err != nil {
+		return err
+	}
+
+	if po := header.Get("purchase_order_id"); kind == "goods_in" && po != "" {
+		var supplier, warehouseID string
+		err = tx.QueryRow(queries.PURCHASE_ORDER_HEADER, po).Scan(&supplier, &warehouseID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrInvalidDraft
+		} else if err != nil {
+			return err
+		}
+		if warehouseID != destination {
+			return models.ErrPurchaseOrderMismatch
+		}
+	}
+
+	return nil
+}
+
+func setGoods(form url.Values, goods interface{}) error {
+	b, err := json.Marshal(goods)
+	if err != nil {
+		return err
+	}
+	form.Set("goods", string(b))
+	return nil
+}
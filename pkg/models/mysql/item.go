@@ -0,0 +1,157 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// ItemModel struct holds methods to query the spare parts catalog
+type ItemModel struct {
+	DB *sql.DB
+}
+
+// Create creates a row in one of the catalog tables
+func (m *ItemModel) Create(table string, rparams, oparams []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.FormTable{
+		TableName: table,
+		RCols:     rparams,
+		OCols:     oparams,
+		Form:      form,
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// All returns all items
+func (m *ItemModel) All() ([]models.Item, error) {
+	var res []models.Item
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ALL_ITEMS)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// UpdateDetails updates the catalog entry of an item for a model. An
+// unknown entry is ErrNoRecord; the entry is looked up rather than relying
+// on the affected rows, which MySQL reports as 0 for an unchanged row.
+func (m *ItemModel) UpdateDetails(id string, cols []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	var found int
+	err = tx.QueryRow("SELECT id FROM item_details WHERE id = ? FOR UPDATE", id).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = models.ErrNoRecord
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	vals := make([]interface{}, len(cols))
+	for i, col := range cols {
+		vals[i] = form.Get(col)
+	}
+
+	n, err := mysequel.Update(mysequel.UpdateTable{
+		Table: mysequel.Table{
+			TableName: "item_details",
+			Columns:   cols,
+			Vals:      vals,
+			Tx:        tx,
+		},
+		WColumns: []string{"id"},
+		WVals:    []string{id},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// DeleteDetails removes the catalog entry of an item for a model
+func (m *ItemModel) DeleteDetails(id int) error {
+	res, err := m.DB.Exec("DELETE FROM item_details WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// Details returns a catalog entry
+func (m *ItemModel) Details(id int) (models.ItemDetails, error) {
+	var res []models.ItemDetails
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ITEM_DETAILS, id)
+	if err != nil {
+		return models.ItemDetails{}, err
+	}
+	if len(res) == 0 {
+		return models.ItemDetails{}, models.ErrNoRecord
+	}
+
+	return res[0], nil
+}
+
+// ModelDetails returns the parts catalog of a model in page order
+func (m *ItemModel) ModelDetails(modelID int) ([]models.ItemDetails, error) {
+	var res []models.ItemDetails
+	err := mysequel.QueryToStructs(&res, m.DB, queries.MODEL_ITEM_DETAILS, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Search returns catalog entries whose foreign part number starts with the search
+func (m *ItemModel) Search(foreignID string) ([]models.ItemDetails, error) {
+	var res []models.ItemDetails
+	err := mysequel.QueryToStructs(&res, m.DB, queries.SEARCH_ITEM_DETAILS, prefix(foreignID))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
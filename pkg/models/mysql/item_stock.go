@@ -0,0 +1,165 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/url"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// ItemGoodsIn receives quantities of catalog items into a warehouse
+func (m *ItemModel) ItemGoodsIn(form url.Values) (int64, error) {
+	return m.itemTransaction(form.Get("document_type"), form.Get("warehouse_id"), form.Get("from_warehouse_id"), form, false, true)
+}
+
+// ItemMovement transfers quantities of catalog items between warehouses.
+// As with unit movements, warehouse_id is the warehouse the items leave
+// and from_warehouse_id the one they go to.
+func (m *ItemModel) ItemMovement(form url.Values) (int64, error) {
+	return m.itemTransaction(form.Get("document_type"), form.Get("from_warehouse_id"), form.Get("warehouse_id"), form, true, true)
+}
+
+// ItemIssue issues quantities of catalog items out of warehouse_id
+func (m *ItemModel) ItemIssue(form url.Values) (int64, error) {
+	return m.itemTransaction(form.Get("document_type"), "", form.Get("warehouse_id"), form, true, false)
+}
+
+// itemTransaction records a document moving the items out of the from
+// warehouse and into the to warehouse, each when asked for
+func (m *ItemModel) itemTransaction(documentType, to, from string, form url.Values, out, in bool) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	var items []models.ItemQuantity
+	err = json.Unmarshal([]byte(form.Get("items")), &items)
+	if err != nil || len(items) == 0 {
+		err = models.ErrInvalidGoods
+		return 0, err
+	}
+
+	kind, issuer := "transfer", from
+	if !out {
+		kind, issuer = "inbound", to
+	} else if !in {
+		kind = "outbound"
+	}
+	err = documentTypeAllows(tx, documentType, kind, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	date, err := documentDate(tx, form.Get("date"))
+	if err != nil {
+		return 0, err
+	}
+
+	number, err := documentNumber(tx, documentType, issuer, date)
+	if err != nil {
+		return 0, err
+	}
+
+	did, err := mysequel.Insert(mysequel.Table{
+		TableName: "document",
+		Columns:   []string{"document_type_id", "warehouse_id", "from_warehouse_id", "date", "number"},
+		Vals:      []interface{}{documentType, to, from, date.Format("2006-01-02 15:04:05"), number},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			err = models.ErrInsufficientStock
+			return 0, err
+		}
+
+		if out {
+			err = m.removeItemStock(tx, did, from, item)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if in {
+			err = m.addItemStock(tx, did, to, item)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return did, nil
+}
+
+func (m *ItemModel) addItemStock(tx *sql.Tx, did int64, warehouse string, item models.ItemQuantity) error {
+	_, err := tx.Exec(queries.ADD_ITEM_STOCK, warehouse, item.ItemID, item.Quantity)
+	if err != nil {
+		return err
+	}
+
+	_, err = mysequel.Insert(mysequel.Table{
+		TableName: "item_stock_ledger",
+		Columns:   []string{"document_id", "warehouse_id", "item_id", "quantity", "price"},
+		Vals:      []interface{}{did, warehouse, item.ItemID, item.Quantity, item.Price},
+		Tx:        tx,
+	})
+	return err
+}
+
+func (m *ItemModel) removeItemStock(tx *sql.Tx, did int64, warehouse string, item models.ItemQuantity) error {
+	res, err := tx.Exec(queries.REMOVE_ITEM_STOCK, item.Quantity, warehouse, item.ItemID, item.Quantity)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrInsufficientStock
+	}
+
+	_, err = mysequel.Insert(mysequel.Table{
+		TableName: "item_stock_ledger",
+		Columns:   []string{"document_id", "warehouse_id", "item_id", "quantity", "price"},
+		Vals:      []interface{}{did, warehouse, item.ItemID, -item.Quantity, item.Price},
+		Tx:        tx,
+	})
+	return err
+}
+
+// Stock returns the on hand quantities of catalog items at a warehouse
+func (m *ItemModel) Stock(warehouseID int) ([]models.ItemStockItem, error) {
+	var res []models.ItemStockItem
+	err := mysequel.QueryToStructs(&res, m.DB, queries.WAREHOUSE_ITEM_STOCK, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Ledger returns every quantity change of a catalog item
+func (m *ItemModel) Ledger(itemID int) ([]models.ItemLedgerEntry, error) {
+	var res []models.ItemLedgerEntry
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ITEM_LEDGER, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
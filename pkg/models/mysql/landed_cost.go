@@ -0,0 +1,128 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// AddDocumentCost attaches a cost line to a goods-in document and adds its
+// share to the cost of every unit the document received, by value or equally
+func (m *Warehouse) AddDocumentCost(documentID int, costType, amount, method, user string) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	total, err := strconv.ParseFloat(amount, 64)
+	if err != nil || total <= 0 || (method != "value" && method != "equal") {
+		err = models.ErrInvalidCost
+		return 0, err
+	}
+
+	var inbound bool
+	var date time.Time
+	err = tx.QueryRow(queries.DOCUMENT_INBOUND, documentID).Scan(&inbound, &date)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = models.ErrNoRecord
+		}
+		return 0, err
+	}
+	if !inbound {
+		err = models.ErrInvalidCost
+		return 0, err
+	}
+
+	err = checkPeriodOpen(tx, date)
+	if err != nil {
+		return 0, err
+	}
+
+	var units []models.DocumentUnitCost
+	err = mysequel.QueryToStructs(&units, tx, queries.DOCUMENT_UNIT_COSTS, documentID, documentID)
+	if err != nil {
+		return 0, err
+	}
+	if len(units) == 0 {
+		err = models.ErrInvalidCost
+		return 0, err
+	}
+
+	id, err := mysequel.Insert(mysequel.Table{
+		TableName: "document_cost",
+		Columns:   []string{"document_id", "cost_type", "amount", "method", "created_by", "created_at"},
+		Vals:      []interface{}{documentID, costType, amount, method, user, time.Now().Format("2006-01-02 15:04:05")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, share := range allocate(math.Round(total), units, method == "value") {
+		_, err = tx.Exec(queries.ADD_MAIN_STOCK_COST, share, units[i].PrimaryID)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(queries.ADD_STOCK_HISTORY_COST, share, units[i].PrimaryID, date)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(queries.ADD_UNIT_LANDED_COST, share, documentID, units[i].PrimaryID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+// allocate splits a whole amount across the units in proportion to their
+// price or equally, leaving any rounding difference on the last unit
+func allocate(total float64, units []models.DocumentUnitCost, byValue bool) []float64 {
+	base := 0.0
+	for _, u := range units {
+		base += u.Price
+	}
+	if base == 0 {
+		byValue = false
+	}
+
+	shares := make([]float64, len(units))
+	allocated := 0.0
+	for i, u := range units[:len(units)-1] {
+		if byValue {
+			shares[i] = math.Round(total * u.Price / base)
+		} else {
+			shares[i] = math.Round(total / float64(len(units)))
+		}
+		allocated += shares[i]
+	}
+	shares[len(units)-1] = total - allocated
+
+	return shares
+}
+
+// DocumentCosts returns the cost lines attached to a document
+func (m *Warehouse) DocumentCosts(documentID int) ([]models.DocumentCost, error) {
+	var res []models.DocumentCost
+	err := mysequel.QueryToStructs(&res, m.DB, queries.DOCUMENT_COSTS, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
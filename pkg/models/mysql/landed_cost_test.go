@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ssrdive/basara/pkg/models"
+)
+
+func TestAllocate(t *testing.T) {
+	units := func(prices ...float64) []models.DocumentUnitCost {
+		res := make([]models.DocumentUnitCost, len(prices))
+		for i, p := range prices {
+			res[i] = models.DocumentUnitCost{PrimaryID: string(rune('A' + i)), Price: p}
+		}
+		return res
+	}
+
+	tests := []struct {
+		name    string
+		total   float64
+		units   []models.DocumentUnitCost
+		byValue bool
+		want    []float64
+	}{
+		{"by value", 1000, units(100, 300), true, []float64{250, 750}},
+		{"equally", 1000, units(100, 300), false, []float64{500, 500}},
+		{"rounding left on last unit", 100, units(1, 1, 1), true, []float64{33, 33, 34}},
+		{"equal rounding left on last unit", 100, units(5, 7, 9), false, []float64{33, 33, 34}},
+		{"zero prices split equally", 90, units(0, 0, 0), true, []float64{30, 30, 30}},
+		{"single unit", 75, units(10), true, []float64{75}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocate(tt.total, tt.units, tt.byValue)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			sum := 0.0
+			for _, s := range got {
+				sum += s
+			}
+			if sum != tt.total {
+				t.Errorf("shares add up to %v, want %v", sum, tt.total)
+			}
+		})
+	}
+}
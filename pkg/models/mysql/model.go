@@ -3,6 +3,7 @@ package mysql
 import (
 	"database/sql"
 	"net/url"
+	"time"
 
 	"github.com/ssrdive/basara/pkg/models"
 	"github.com/ssrdive/basara/pkg/sql/queries"
@@ -62,3 +63,53 @@ func (m *MModel) UserAll() ([]models.AllUserItem, error) {
 
 	return res, nil
 }
+
+// CreatePrice adds a price list entry for a model
+func (m *MModel) CreatePrice(rparams, oparams []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.FormTable{
+		TableName: "model_price",
+		RCols:     rparams,
+		OCols:     oparams,
+		Form:      form,
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// PriceHistory returns every price list entry of a model, latest first
+func (m *MModel) PriceHistory(modelID int) ([]models.ModelPrice, error) {
+	var res []models.ModelPrice
+	err := mysequel.QueryToStructs(&res, m.DB, queries.MODEL_PRICE_HISTORY, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ActivePrices returns the prices in effect for each model on a date
+func (m *MModel) ActivePrices(date time.Time) ([]models.ModelPrice, error) {
+	var res []models.ModelPrice
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ACTIVE_MODEL_PRICES, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
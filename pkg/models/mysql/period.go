@@ -0,0 +1,170 @@
+package mysql
+
+import (
+	"database/sql"
+	"net/url"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// ClosePeriod closes a month that has ended, keeping a snapshot of the stock
+// valuation at its end. Documents dated in a closed month, or any month
+// before it, can no longer be created or changed.
+func (m *ReportModel) ClosePeriod(period, user string) error {
+	start, err := time.ParseInLocation("2006-01", period, time.Local)
+	if err != nil {
+		return models.ErrInvalidPeriod
+	}
+	end := start.AddDate(0, 1, 0)
+	if end.After(time.Now()) {
+		return models.ErrInvalidPeriod
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	var closed int
+	err = tx.QueryRow(queries.PERIOD_CLOSED, period).Scan(&closed)
+	if err != nil {
+		return err
+	}
+	if closed > 0 {
+		err = models.ErrPeriodLocked
+		return err
+	}
+
+	err = checkPeriodSettled(tx, end)
+	if err != nil {
+		return err
+	}
+
+	_, err = mysequel.Insert(mysequel.Table{
+		TableName: "accounting_period",
+		Columns:   []string{"period", "closed_by", "closed_at"},
+		Vals:      []interface{}{period, user, time.Now().Format("2006-01-02 15:04:05")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return err
+	}
+
+	t := end.Format("2006-01-02 15:04:05")
+	var rows []models.ValuationByWarehouseModel
+	err = mysequel.QueryToStructs(&rows, tx, queries.VALUATION_BY_WAREHOUSE_MODEL, t, t, t)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		_, err = mysequel.Insert(mysequel.Table{
+			TableName: "valuation_snapshot",
+			Columns:   []string{"period", "warehouse_id", "warehouse", "model_id", "model", "count", "total", "average"},
+			Vals:      []interface{}{period, r.WarehouseID, r.Warehouse, r.ModelID, r.Model, r.Count, r.Total, r.Average},
+			Tx:        tx,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Periods returns the closed months, latest first
+func (m *ReportModel) Periods() ([]models.AccountingPeriod, error) {
+	var res []models.AccountingPeriod
+	err := mysequel.QueryToStructs(&res, m.DB, queries.CLOSED_PERIODS)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// PeriodValuation returns the valuation frozen when a month was closed
+func (m *ReportModel) PeriodValuation(period string) (models.Valuation, error) {
+	start, err := time.ParseInLocation("2006-01", period, time.Local)
+	if err != nil {
+		return models.Valuation{}, models.ErrInvalidPeriod
+	}
+
+	var closed int
+	err = m.DB.QueryRow(queries.PERIOD_CLOSED, period).Scan(&closed)
+	if err != nil {
+		return models.Valuation{}, err
+	}
+	if closed == 0 {
+		return models.Valuation{}, models.ErrNoRecord
+	}
+
+	v := models.Valuation{AsAt: asAtLabel(start.AddDate(0, 1, 0))}
+
+	err = mysequel.QueryToStructs(&v.ByWarehouse, m.DB, queries.SNAPSHOT_BY_WAREHOUSE, period)
+	if err != nil {
+		return models.Valuation{}, err
+	}
+
+	err = mysequel.QueryToStructs(&v.ByModel, m.DB, queries.SNAPSHOT_BY_MODEL, period)
+	if err != nil {
+		return models.Valuation{}, err
+	}
+
+	err = mysequel.QueryToStructs(&v.ByWarehouseModel, m.DB, queries.SNAPSHOT_BY_WAREHOUSE_MODEL, period)
+	if err != nil {
+		return models.Valuation{}, err
+	}
+
+	return v, nil
+}
+
+// checkPeriodSettled fails while movements dated before the end of the
+// period wait for approval or open drafts are dated before it, as closing
+// would lock them before they could be posted
+func checkPeriodSettled(tx *sql.Tx, end time.Time) error {
+	var pending int
+	err := tx.QueryRow(queries.PENDING_APPROVALS_BEFORE, end.Format("2006-01-02 15:04:05")).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return models.ErrPeriodUnsettled
+	}
+
+	rows, err := tx.Query(queries.OPEN_DRAFT_HEADERS)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var header string
+		err = rows.Scan(&header)
+		if err != nil {
+			return err
+		}
+		values, err := url.ParseQuery(header)
+		if err != nil {
+			return err
+		}
+		// Draft dates are either a day or a day and time, so the day alone
+		// places them
+		date := values.Get("date")
+		if len(date) >= 10 && date[:10] < end.Format("2006-01-02") {
+			return models.ErrPeriodUnsettled
+		}
+	}
+
+	return rows.Err()
+}
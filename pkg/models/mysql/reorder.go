@@ -0,0 +1,73 @@
+package mysql
+
+import (
+	"database/sql"
+	"net/url"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// ReorderModel struct holds methods to query reorder_level table
+type ReorderModel struct {
+	DB *sql.DB
+}
+
+// Set creates or updates the stock levels of a model at a warehouse
+func (m *ReorderModel) Set(form url.Values) error {
+	_, err := m.DB.Exec(queries.SET_REORDER_LEVEL, form.Get("warehouse_id"), form.Get("model_id"), form.Get("min_quantity"), form.Get("max_quantity"))
+	return err
+}
+
+// All returns all configured stock levels
+func (m *ReorderModel) All() ([]models.ReorderLevel, error) {
+	var res []models.ReorderLevel
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ALL_REORDER_LEVELS)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Suggestions returns the warehouse and model pairs below their minimum
+// level with the quantity needed to bring them up to the maximum
+func (m *ReorderModel) Suggestions() ([]models.ReorderSuggestion, error) {
+	var res []models.ReorderSuggestion
+	err := mysequel.QueryToStructs(&res, m.DB, queries.REORDER_SUGGESTIONS)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// AlertsDue clears the alerts of levels whose stock has recovered and returns
+// the suggestions not alerted on since their stock fell below the minimum
+func (m *ReorderModel) AlertsDue() ([]models.ReorderSuggestion, error) {
+	_, err := m.DB.Exec(queries.CLEAR_RECOVERED_REORDER_ALERTS)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []models.ReorderSuggestion
+	err = mysequel.QueryToStructs(&res, m.DB, queries.REORDER_ALERTS_DUE)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// MarkAlerted records that an alert was sent for the suggestions
+func (m *ReorderModel) MarkAlerted(suggestions []models.ReorderSuggestion) error {
+	for _, s := range suggestions {
+		_, err := m.DB.Exec(queries.MARK_REORDER_ALERTED, s.WarehouseID, s.ModelID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// ReportModel struct holds methods to build stock reports
+type ReportModel struct {
+	DB *sql.DB
+}
+
+// Valuation returns the cost of stock held at a point in time grouped
+// by warehouse, by model and by warehouse and model. The end of a closed
+// month is answered from the snapshot taken when it was closed.
+func (m *ReportModel) Valuation(at time.Time) (models.Valuation, error) {
+	if at.Day() == 1 && at.Hour() == 0 && at.Minute() == 0 && at.Second() == 0 {
+		v, err := m.PeriodValuation(at.AddDate(0, -1, 0).Format("2006-01"))
+		if err == nil {
+			return v, nil
+		} else if !errors.Is(err, models.ErrNoRecord) {
+			return models.Valuation{}, err
+		}
+	}
+
+	t := at.Format("2006-01-02 15:04:05")
+	v := models.Valuation{AsAt: asAtLabel(at)}
+
+	err := mysequel.QueryToStructs(&v.ByWarehouse, m.DB, queries.VALUATION_BY_WAREHOUSE, t, t, t)
+	if err != nil {
+		return models.Valuation{}, err
+	}
+
+	err = mysequel.QueryToStructs(&v.ByModel, m.DB, queries.VALUATION_BY_MODEL, t, t, t)
+	if err != nil {
+		return models.Valuation{}, err
+	}
+
+	err = mysequel.QueryToStructs(&v.ByWarehouseModel, m.DB, queries.VALUATION_BY_WAREHOUSE_MODEL, t, t, t)
+	if err != nil {
+		return models.Valuation{}, err
+	}
+
+	return v, nil
+}
+
+// asAtLabel shows a point in time reports run up to. Midnight stands for the
+// close of the day before, which is how a date given to a report is read.
+func asAtLabel(at time.Time) string {
+	if at.Hour() == 0 && at.Minute() == 0 && at.Second() == 0 && at.Nanosecond() == 0 {
+		return at.AddDate(0, 0, -1).Format("2006-01-02") + " 23:59:59"
+	}
+	return at.Format("2006-01-02 15:04:05")
+}
+
+// StockAsAt returns the units held at a point in time, optionally
+// limited to one warehouse
+func (m *ReportModel) StockAsAt(at time.Time, warehouse string) ([]models.AsAtStockItem, error) {
+	t := at.Format("2006-01-02 15:04:05")
+	w := mysequel.NewNullString(warehouse)
+
+	var res []models.AsAtStockItem
+	err := mysequel.QueryToStructs(&res, m.DB, queries.STOCK_AS_AT, t, t, t, w, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Ageing groups the stock into age buckets by warehouse and model. Bounds
+// are the ascending upper limits in days of each bucket except the last,
+// which holds everything older. When total is set units are aged from
+// their first goods-in rather than from arrival at the current warehouse.
+func (m *ReportModel) Ageing(bounds []int, total bool) (models.Ageing, error) {
+	var units []models.AgeingUnit
+	err := mysequel.QueryToStructs(&units, m.DB, queries.AGEING_UNITS)
+	if err != nil {
+		return models.Ageing{}, err
+	}
+
+	labels := bucketLabels(bounds)
+	a := models.Ageing{Buckets: labels, Items: []models.AgeingItem{}}
+
+	type key struct {
+		warehouse, model, bucket int
+	}
+	index := make(map[key]int)
+	for _, u := range units {
+		age := u.Age
+		if total {
+			age = u.TotalAge
+		}
+
+		b := len(bounds)
+		for i, bound := range bounds {
+			if age <= bound {
+				b = i
+				break
+			}
+		}
+
+		k := key{u.WarehouseID, u.ModelID, b}
+		i, ok := index[k]
+		if !ok {
+			i = len(a.Items)
+			index[k] = i
+			a.Items = append(a.Items, models.AgeingItem{
+				WarehouseID: u.WarehouseID,
+				Warehouse:   u.Warehouse,
+				ModelID:     u.ModelID,
+				Model:       u.Model,
+				Bucket:      labels[b],
+			})
+		}
+		a.Items[i].Count++
+		a.Items[i].Value += u.Price
+	}
+
+	return a, nil
+}
+
+// bucketLabels names the buckets for the given bounds, e.g. 30, 60
+// gives 0-30, 31-60 and 60+
+func bucketLabels(bounds []int) []string {
+	var labels []string
+	lower := 0
+	for _, bound := range bounds {
+		labels = append(labels, fmt.Sprintf("%d-%d", lower, bound))
+		lower = bound + 1
+	}
+	if len(bounds) == 0 {
+		return append(labels, "0+")
+	}
+	return append(labels, fmt.Sprintf("%d+", bounds[len(bounds)-1]))
+}
+
+// periodFormats maps the report periods to the MySQL format naming them and
+// a function naming the period of a day the same way
+var periodFormats = map[string]struct {
+	sql  string
+	name func(time.Time) string
+}{
+	"day":   {"%Y-%m-%d", func(t time.Time) string { return t.Format("2006-01-02") }},
+	"week":  {"%x-W%v", func(t time.Time) string { y, w := t.ISOWeek(); return fmt.Sprintf("%d-W%02d", y, w) }},
+	"month": {"%Y-%m", func(t time.Time) string { return t.Format("2006-01") }},
+}
+
+// MovementSummary returns inflows, outflows, transfers and the opening and
+// closing balance per warehouse and model for every period between from and
+// to, including periods without any movement
+func (m *ReportModel) MovementSummary(from, to time.Time, period string) ([]models.MovementSummaryItem, error) {
+	format, ok := periodFormats[period]
+	if !ok {
+		return nil, models.ErrInvalidPeriod
+	}
+
+	f := from.Format("2006-01-02 15:04:05")
+	t := to.Format("2006-01-02 15:04:05")
+
+	var opening []models.ValuationByWarehouseModel
+	err := mysequel.QueryToStructs(&opening, m.DB, queries.VALUATION_BY_WAREHOUSE_MODEL, f, f, f)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []models.MovementEvent
+	err = mysequel.QueryToStructs(&events, m.DB, queries.MOVEMENT_EVENTS, format.sql, f, t, format.sql, f, t, format.sql, f, t)
+	if err != nil {
+		return nil, err
+	}
+
+	var periods []string
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		if name := format.name(d); len(periods) == 0 || periods[len(periods)-1] != name {
+			periods = append(periods, name)
+		}
+	}
+
+	type key struct {
+		warehouse, model int
+	}
+	type series struct {
+		item    models.MovementSummaryItem
+		balance int
+		events  map[string][]models.MovementEvent
+	}
+	all := make(map[key]*series)
+	var keys []key
+	get := func(k key, warehouse, model string) *series {
+		if s, ok := all[k]; ok {
+			return s
+		}
+		s := &series{
+			item:   models.MovementSummaryItem{WarehouseID: k.warehouse, Warehouse: warehouse, ModelID: k.model, Model: model},
+			events: make(map[string][]models.MovementEvent),
+		}
+		all[k] = s
+		keys = append(keys, k)
+		return s
+	}
+	for _, o := range opening {
+		get(key{o.WarehouseID, o.ModelID}, o.Warehouse, o.Model).balance = o.Count
+	}
+	for _, e := range events {
+		s := get(key{e.WarehouseID, e.ModelID}, e.Warehouse, e.Model)
+		s.events[e.Period] = append(s.events[e.Period], e)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := all[keys[i]].item, all[keys[j]].item
+		if a.Warehouse != b.Warehouse {
+			return a.Warehouse < b.Warehouse
+		}
+		if a.WarehouseID != b.WarehouseID {
+			return a.WarehouseID < b.WarehouseID
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.ModelID < b.ModelID
+	})
+
+	res := []models.MovementSummaryItem{}
+	for _, k := range keys {
+		s := all[k]
+		for _, p := range periods {
+			item := s.item
+			item.Period = p
+			item.Opening = s.balance
+			item.Closing = s.balance
+			for _, e := range s.events[p] {
+				switch e.Kind {
+				case "inflow":
+					item.Inflow += e.Count
+					item.Closing += e.Count
+				case "transfer_in":
+					item.TransferIn += e.Count
+					item.Closing += e.Count
+				case "transfer_out":
+					item.TransferOut += e.Count
+					item.Closing -= e.Count
+				case "outflow":
+					item.Outflow += e.Count
+					item.Closing -= e.Count
+				}
+			}
+			s.balance = item.Closing
+			res = append(res, item)
+		}
+	}
+
+	return res, nil
+}
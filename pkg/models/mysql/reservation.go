@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// ReservationModel struct holds methods to query reservation table
+type ReservationModel struct {
+	DB *sql.DB
+}
+
+// Create reserves an in stock unit for a customer or a warehouse until the
+// expiry date. A date without a time reserves the unit to the end of the day.
+func (m *ReservationModel) Create(form url.Values, user string) (int64, error) {
+	expires, err := time.ParseInLocation("2006-01-02 15:04:05", form.Get("expires_at"), time.Local)
+	if err != nil {
+		var day time.Time
+		day, err = time.ParseInLocation("2006-01-02", form.Get("expires_at"), time.Local)
+		if err != nil {
+			return 0, models.ErrInvalidExpiry
+		}
+		expires = time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, time.Local)
+	}
+	if !expires.After(time.Now()) {
+		return 0, models.ErrInvalidExpiry
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	var count int
+	err = tx.QueryRow(queries.UNIT_IN_STOCK_COUNT, form.Get("primary_id")).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		err = models.ErrInvalidUnits
+		return 0, err
+	}
+
+	err = tx.QueryRow(queries.ACTIVE_RESERVATION_COUNT, form.Get("primary_id")).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if count != 0 {
+		err = models.ErrReserved
+		return 0, err
+	}
+
+	id, err := mysequel.Insert(mysequel.Table{
+		TableName: "reservation",
+		Columns:   []string{"primary_id", "customer_id", "warehouse_id", "expires_at", "created_by", "created_at"},
+		Vals:      []interface{}{form.Get("primary_id"), form.Get("customer_id"), form.Get("warehouse_id"), expires.Format("2006-01-02 15:04:05"), user, time.Now().Format("2006-01-02 15:04:05")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Release releases an active reservation made by the user, or any active
+// reservation when admin is set
+func (m *ReservationModel) Release(id int, user string, admin bool) error {
+	var createdBy string
+	err := m.DB.QueryRow("SELECT created_by FROM reservation WHERE id = ? AND released_at IS NULL", id).Scan(&createdBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrNoRecord
+		}
+		return err
+	}
+	if createdBy != user && !admin {
+		return models.ErrNotOwner
+	}
+
+	res, err := m.DB.Exec("UPDATE reservation SET released_at = NOW() WHERE id = ? AND released_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// Active returns reservations that have not been released or expired
+func (m *ReservationModel) Active() ([]models.Reservation, error) {
+	var res []models.Reservation
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ACTIVE_RESERVATIONS)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,142 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// SupplierModel struct holds methods to query supplier and purchase order tables
+type SupplierModel struct {
+	DB *sql.DB
+}
+
+// Create creates a supplier
+func (m *SupplierModel) Create(rparams, oparams []string, form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.FormTable{
+		TableName: "supplier",
+		RCols:     rparams,
+		OCols:     oparams,
+		Form:      form,
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// All returns all suppliers
+func (m *SupplierModel) All() ([]models.Supplier, error) {
+	var res []models.Supplier
+	err := mysequel.QueryToStructs(&res, m.DB, queries.ALL_SUPPLIERS)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CreatePurchaseOrder creates a purchase order with the expected models and quantities
+func (m *SupplierModel) CreatePurchaseOrder(form url.Values) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	var items []models.PurchaseOrderGoods
+	err = json.Unmarshal([]byte(form.Get("items")), &items)
+	if err != nil || len(items) == 0 {
+		err = models.ErrInvalidPurchaseOrder
+		return 0, err
+	}
+	for _, item := range items {
+		// Prices are whole amounts, as the purchase order view reads them
+		price, perr := strconv.ParseFloat(item.Price, 64)
+		if item.Model == "" || item.Quantity <= 0 || perr != nil || price < 0 || price != math.Trunc(price) {
+			err = models.ErrInvalidPurchaseOrder
+			return 0, err
+		}
+	}
+
+	id, err := mysequel.Insert(mysequel.Table{
+		TableName: "purchase_order",
+		Columns:   []string{"supplier_id", "warehouse_id", "date"},
+		Vals:      []interface{}{form.Get("supplier_id"), form.Get("warehouse_id"), form.Get("date")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range items {
+		_, err = mysequel.Insert(mysequel.Table{
+			TableName: "purchase_order_item",
+			Columns:   []string{"purchase_order_id", "model_id", "quantity", "received", "price"},
+			Vals:      []interface{}{id, item.Model, item.Quantity, 0, item.Price},
+			Tx:        tx,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
+}
+
+// PurchaseOrder returns a purchase order with its received and outstanding quantities
+func (m *SupplierModel) PurchaseOrder(id int) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := m.DB.QueryRow(queries.PURCHASE_ORDER, id).Scan(&po.ID, &po.Date, &po.SupplierID, &po.Supplier, &po.WarehouseID, &po.Warehouse)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PurchaseOrder{}, models.ErrNoRecord
+		}
+		return models.PurchaseOrder{}, err
+	}
+
+	err = mysequel.QueryToStructs(&po.Items, m.DB, queries.PURCHASE_ORDER_ITEMS, id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	return po, nil
+}
+
+// Outstanding returns ordered models that have not been fully received
+func (m *SupplierModel) Outstanding() ([]models.OutstandingPurchaseItem, error) {
+	var res []models.OutstandingPurchaseItem
+	err := mysequel.QueryToStructs(&res, m.DB, queries.OUTSTANDING_PURCHASE_ITEMS)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
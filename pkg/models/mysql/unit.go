@@ -0,0 +1,172 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ssrdive/basara/pkg/models"
+	"github.com/ssrdive/basara/pkg/sql/queries"
+	"github.com/ssrdive/mysequel"
+)
+
+// UnitModel struct holds methods to query a single serialised unit
+type UnitModel struct {
+	DB *sql.DB
+}
+
+// Detail returns where a unit is, whether it is reserved or sold, its
+// attachments and every warehouse it has been in
+func (m *UnitModel) Detail(primaryID string) (models.UnitDetail, error) {
+	var id models.UnitIdentity
+	err := m.DB.QueryRow(queries.UNIT_IDENTITY, primaryID, primaryID).Scan(&id.PrimaryID, &id.SecondaryID, &id.ModelID, &id.Model)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UnitDetail{}, models.ErrNoRecord
+		}
+		return models.UnitDetail{}, err
+	}
+
+	d := models.UnitDetail{
+		PrimaryID:   id.PrimaryID,
+		SecondaryID: id.SecondaryID,
+		ModelID:     id.ModelID,
+		Model:       id.Model,
+		Status:      "out_of_stock",
+	}
+
+	var current []models.UnitLocation
+	err = mysequel.QueryToStructs(&current, m.DB, queries.UNIT_CURRENT, primaryID)
+	if err != nil {
+		return models.UnitDetail{}, err
+	}
+	if len(current) > 0 {
+		d.Current = &current[0]
+		d.Status = "in_stock"
+	}
+
+	var reservations []models.Reservation
+	err = mysequel.QueryToStructs(&reservations, m.DB, queries.UNIT_RESERVATION, primaryID)
+	if err != nil {
+		return models.UnitDetail{}, err
+	}
+	if len(reservations) > 0 {
+		d.Reservation = &reservations[0]
+		d.Status = "reserved"
+	}
+
+	var sales []models.UnitSale
+	err = mysequel.QueryToStructs(&sales, m.DB, queries.UNIT_SALE, primaryID)
+	if err != nil {
+		return models.UnitDetail{}, err
+	}
+	if len(sales) > 0 && d.Current == nil {
+		d.Sale = &sales[0]
+		d.Status = "sold"
+	}
+
+	err = mysequel.QueryToStructs(&d.Attachments, m.DB, queries.UNIT_ATTACHMENTS, primaryID)
+	if err != nil {
+		return models.UnitDetail{}, err
+	}
+
+	err = mysequel.QueryToStructs(&d.Timeline, m.DB, queries.UNIT_TIMELINE, primaryID, primaryID)
+	if err != nil {
+		return models.UnitDetail{}, err
+	}
+
+	return d, nil
+}
+
+// Exists reports whether a unit has ever been received
+func (m *UnitModel) Exists(primaryID string) (bool, error) {
+	var id models.UnitIdentity
+	err := m.DB.QueryRow(queries.UNIT_IDENTITY, primaryID, primaryID).Scan(&id.PrimaryID, &id.SecondaryID, &id.ModelID, &id.Model)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Attach records a file uploaded for a unit
+func (m *UnitModel) Attach(primaryID, name, key, user string) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	id, err := mysequel.Insert(mysequel.Table{
+		TableName: "unit_attachment",
+		Columns:   []string{"primary_id", "name", "s3_key", "created_by", "created_at"},
+		Vals:      []interface{}{primaryID, name, key, user, time.Now().Format("2006-01-02 15:04:05")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// LookupPrimary returns the in stock unit with the primary number
+func (m *UnitModel) LookupPrimary(number string) (models.LookupItem, error) {
+	return m.lookupOne(queries.LOOKUP_BY_PRIMARY, number)
+}
+
+// LookupSecondary returns the in stock unit with the secondary number
+func (m *UnitModel) LookupSecondary(number string) (models.LookupItem, error) {
+	return m.lookupOne(queries.LOOKUP_BY_SECONDARY, number)
+}
+
+func (m *UnitModel) lookupOne(query, number string) (models.LookupItem, error) {
+	var l models.LookupItem
+	err := m.DB.QueryRow(query, number).Scan(&l.PrimaryID, &l.SecondaryID, &l.Model, &l.WarehouseID, &l.Warehouse)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LookupItem{}, models.ErrNoRecord
+		}
+		return models.LookupItem{}, err
+	}
+
+	return l, nil
+}
+
+// Lookup returns the in stock units matching any of the numbers by either
+// primary or secondary number, and the numbers that matched nothing
+func (m *UnitModel) Lookup(numbers []string) (models.LookupResult, error) {
+	args := make([]interface{}, 0, 2*len(numbers))
+	for i := 0; i < 2; i++ {
+		for _, n := range numbers {
+			args = append(args, n)
+		}
+	}
+
+	res := models.LookupResult{Found: []models.LookupItem{}, Missing: []string{}}
+	err := mysequel.QueryToStructs(&res.Found, m.DB, queries.LOOKUP_BATCH(placeholders(len(numbers))), args...)
+	if err != nil {
+		return models.LookupResult{}, err
+	}
+
+	matched := make(map[string]bool)
+	for _, l := range res.Found {
+		matched[l.PrimaryID] = true
+		matched[l.SecondaryID] = true
+	}
+	for _, n := range numbers {
+		if !matched[n] {
+			res.Missing = append(res.Missing, n)
+		}
+	}
+
+	return res, nil
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ssrdive/basara/pkg/models"
@@ -73,18 +76,6 @@ func (m *Warehouse) Create(rparams, oparams []string, form url.Values) (int64, e
 	return id, nil
 }
 
-func (m *Warehouse) SecNumberModel(primaryNumber string) (models.SecNumberModel, error) {
-	var secMod models.SecNumberModel
-
-	err := m.DB.QueryRow(queries.SEC_MODEL, primaryNumber).Scan(&secMod.SecondaryNumber, &secMod.Model)
-
-	if err != nil {
-		return models.SecNumberModel{}, err
-	}
-
-	return secMod, nil
-}
-
 func (m *Warehouse) Agewise(model, age int) ([]models.AgeWiseItem, error) {
 	var res []models.AgeWiseItem
 	err := mysequel.QueryToStructs(&res, m.DB, queries.AGE_WISE_SEARCH, age, model)
@@ -125,24 +116,158 @@ func (m *Warehouse) RecentDocs() ([]models.DocsItem, error) {
 	return res, nil
 }
 
-func (m *Warehouse) Search(search string) ([]models.SearchResultItem, error) {
-	var k sql.NullString
-	if search == "" {
-		k = sql.NullString{}
-	} else {
-		k = sql.NullString{
-			Valid:  true,
-			String: "%" + search + "%",
+// searchSorts maps the sort keys accepted by Search to result columns
+var searchSorts = map[string]string{
+	"document_id":  "U.document_id",
+	"model":        "U.model",
+	"warehouse":    "U.warehouse",
+	"primary_id":   "U.primary_id",
+	"secondary_id": "U.secondary_id",
+	"price":        "U.price",
+	"age":          "U.in_stock_for",
+	"total_age":    "U.total_age",
+}
+
+// searchSource is one of the unit sets Search unions. Columns maps each
+// filter to the source's own expression so the filters are applied inside
+// every branch, where the indexes can be used, rather than on the union.
+type searchSource struct {
+	query   string
+	from    string
+	where   []string
+	columns map[string]string
+}
+
+var searchInStock = searchSource{
+	query: queries.SEARCH_IN_STOCK,
+	from:  queries.SEARCH_IN_STOCK_FROM,
+	columns: map[string]string{
+		"model_id": "MS.model_id", "warehouse_id": "DD.warehouse_id", "primary_id": "MS.primary_id",
+		"secondary_id": "MS.secondary_id", "price": "MS.price", "in_stock_for": "DATEDIFF(NOW(), DD.date)",
+		"document_type_id": "DD.document_type_id", "model": "M.name", "warehouse": "W.name",
+	},
+}
+
+var searchSold = searchSource{
+	query: queries.SEARCH_SOLD,
+	from:  queries.SEARCH_SOLD_FROM,
+	columns: map[string]string{
+		"model_id": "SI.model_id", "warehouse_id": "DD.from_warehouse_id", "primary_id": "SI.primary_id",
+		"secondary_id": "SI.secondary_id", "price": "SI.price", "in_stock_for": "0",
+		"document_type_id": "DD.document_type_id", "model": "M.name", "warehouse": "W.name",
+		"customer": "C.name", "customer_nic": "C.nic",
+	},
+}
+
+var searchMoved = searchSource{
+	query: queries.SEARCH_MOVED,
+	from:  queries.SEARCH_MOVED_FROM,
+	where: []string{queries.SEARCH_MOVED_NOT_SOLD},
+	columns: map[string]string{
+		"model_id": "SH.model_id", "warehouse_id": "DD.warehouse_id", "primary_id": "SH.primary_id",
+		"secondary_id": "SH.secondary_id", "price": "SH.price", "in_stock_for": "DATEDIFF(SH.date_out, SH.date_in)",
+		"document_type_id": "DD.document_type_id", "model": "M.name", "warehouse": "W.name",
+	},
+}
+
+// searchText lists the columns the free text search looks in
+var searchText = []string{"primary_id", "secondary_id", "model", "warehouse", "customer", "customer_nic"}
+
+// Search returns a page of units matching the filter and the total number
+// of matches. Sold and moved units are only included when asked for.
+func (m *Warehouse) Search(f models.SearchFilter) ([]models.SearchResultItem, int, error) {
+	sources := []searchSource{searchInStock}
+	if f.IncludeHistory {
+		sources = append(sources, searchSold, searchMoved)
+	}
+
+	filters := []struct {
+		column string
+		cond   string
+		value  string
+	}{
+		{"model_id", "= ?", f.Model},
+		{"warehouse_id", "= ?", f.Warehouse},
+		{"primary_id", "LIKE ?", prefix(f.PrimaryPrefix)},
+		{"secondary_id", "LIKE ?", prefix(f.SecondaryPrefix)},
+		{"price", ">= ?", f.MinPrice},
+		{"price", "<= ?", f.MaxPrice},
+		{"in_stock_for", ">= ?", f.MinAge},
+		{"in_stock_for", "<= ?", f.MaxAge},
+		{"document_type_id", "= ?", f.DocumentType},
+	}
+
+	var queryParts, countParts []string
+	var args []interface{}
+	for _, source := range sources {
+		where := append([]string{"1 = 1"}, source.where...)
+		if f.Search != "" {
+			var text []string
+			for _, column := range searchText {
+				if expr, ok := source.columns[column]; ok {
+					text = append(text, expr+" LIKE ?")
+					args = append(args, contains(f.Search))
+				}
+			}
+			where = append(where, "("+strings.Join(text, " OR ")+")")
+		}
+		for _, filter := range filters {
+			if filter.value != "" {
+				where = append(where, source.columns[filter.column]+" "+filter.cond)
+				args = append(args, filter.value)
+			}
+		}
+		w := " WHERE " + strings.Join(where, " AND ")
+		queryParts = append(queryParts, source.query+w)
+		countParts = append(countParts, source.from+w)
+	}
+
+	order := "U.primary_id ASC"
+	if col, ok := searchSorts[strings.TrimPrefix(f.Sort, "-")]; ok {
+		if strings.HasPrefix(f.Sort, "-") {
+			order = col + " DESC"
+		} else {
+			order = col + " ASC"
 		}
 	}
 
 	var res []models.SearchResultItem
-	err := mysequel.QueryToStructs(&res, m.DB, queries.SEARCH, k)
+	err := mysequel.QueryToStructs(&res, m.DB, queries.SEARCH(queryParts, order), append(args, f.Size, (f.Page-1)*f.Size)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return res, nil
+	// A short first page already holds every match, so the count is only
+	// run when there may be more
+	total := len(res)
+	if f.Page > 1 || total == f.Size {
+		err = m.DB.QueryRow(queries.SEARCH_COUNT(countParts), args...).Scan(&total)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return res, total, nil
+}
+
+// prefix turns a value into a LIKE pattern matching strings starting with it
+func prefix(s string) string {
+	if s == "" {
+		return ""
+	}
+	return likeEscape(s) + "%"
+}
+
+// contains turns a value into a LIKE pattern matching strings containing it
+func contains(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "%" + likeEscape(s) + "%"
+}
+
+func likeEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
 }
 
 func (m *Warehouse) History(id string) ([]models.HistoryItem, error) {
@@ -177,7 +302,10 @@ func (m *Warehouse) All() ([]models.AllWarehouseItem, error) {
 	return res, nil
 }
 
-func (m *Warehouse) Movement(form url.Values) (int64, error) {
+// Movement transfers units between warehouses. When an approval rule or the
+// document type asks for it the document is left pending and the units stay
+// where they are until it is approved.
+func (m *Warehouse) Movement(form url.Values, user string) (int64, error) {
 	tx, err := m.DB.Begin()
 	if err != nil {
 		return 0, err
@@ -190,61 +318,124 @@ func (m *Warehouse) Movement(form url.Values) (int64, error) {
 		_ = tx.Commit()
 	}()
 
+	did, err := m.movement(tx, form, user)
+	if err != nil {
+		return 0, err
+	}
+
+	return did, nil
+}
+
+// movement records a movement within the transaction given
+func (m *Warehouse) movement(tx *sql.Tx, form url.Values, user string) (int64, error) {
 	var movementItems []models.GoodsMovement
 	json.Unmarshal([]byte(form.Get("goods")), &movementItems)
 
-	var primaryIDs []string
-
-	primaryIDStr := ""
+	err := documentTypeAllows(tx, form.Get("document_type"), "transfer", form.Get("warehouse_id"), form.Get("from_warehouse_id"))
+	if err != nil {
+		return 0, err
+	}
 
-	for i, item := range movementItems {
+	var primaryIDs []interface{}
+	for _, item := range movementItems {
 		primaryIDs = append(primaryIDs, item.PrimaryNumber)
-		primaryIDStr += "'" + item.PrimaryNumber + "'"
-		if i != len(movementItems)-1 {
-			primaryIDStr += ","
-		}
 	}
 
-	var res []models.ValidTransfer
-	err = mysequel.QueryToStructs(&res, m.DB, queries.INVALID_TRANSFERS(primaryIDStr), form.Get("warehouse_id"))
+	res, err := movableUnits(tx, form.Get("warehouse_id"), primaryIDs, 0)
 	if err != nil {
 		return 0, err
 	}
 
-	if len(movementItems) != len(res) {
-		return 0, errors.New("Invalid Transfers")
+	err = m.checkReservations(tx, user, "0", form.Get("from_warehouse_id"), primaryIDs)
+	if err != nil {
+		return 0, err
 	}
 
-	for _, shentry := range res {
-		_, err := mysequel.Insert(mysequel.Table{
-			TableName: "stock_history",
-			Columns:   []string{"document_id", "model_id", "primary_id", "secondary_id", "price", "date_in", "date_out"},
-			Vals:      []interface{}{shentry.DocumentID, shentry.ModelID, shentry.PrimaryID, shentry.SecondaryID, shentry.Price, shentry.Date.Format("2006-01-02 15:05:05"), form.Get("date")},
-			Tx:        tx,
-		})
-		if err != nil {
-			tx.Rollback()
-			return 0, err
-		}
+	date, err := documentDate(tx, form.Get("date"))
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkLegDates(res, date)
+	if err != nil {
+		return 0, err
+	}
+
+	number, err := documentNumber(tx, form.Get("document_type"), form.Get("warehouse_id"), date)
+	if err != nil {
+		return 0, err
 	}
 
 	did, err := mysequel.Insert(mysequel.Table{
 		TableName: "document",
-		Columns:   []string{"document_type_id", "warehouse_id", "from_warehouse_id", "date"},
-		Vals:      []interface{}{form.Get("document_type"), form.Get("from_warehouse_id"), form.Get("warehouse_id"), time.Now().Format("2006-01-02 15:04:05")},
+		Columns:   []string{"document_type_id", "warehouse_id", "from_warehouse_id", "date", "number"},
+		Vals:      []interface{}{form.Get("document_type"), form.Get("from_warehouse_id"), form.Get("warehouse_id"), date.Format("2006-01-02 15:04:05"), number},
 		Tx:        tx,
 	})
 	if err != nil {
-		tx.Rollback()
 		return 0, err
 	}
 
-	_, err = tx.Exec(fmt.Sprintf("DELETE FROM main_stock WHERE primary_id IN (%s)", primaryIDStr))
+	pending, err := m.requestApproval(tx, did, form.Get("document_type"), form.Get("warehouse_id"), form.Get("from_warehouse_id"), user, res)
+	if err != nil {
+		return 0, err
+	}
+	if pending {
+		return did, nil
+	}
+
+	err = moveUnits(tx, did, date, res)
 	if err != nil {
-		tx.Rollback()
 		return 0, err
 	}
 
+	return did, nil
+}
+
+// movableUnits returns the stock entries of the units, failing unless every
+// one of them is in the source warehouse and not held by a pending approval
+// other than that of the document given
+func movableUnits(tx *sql.Tx, source string, pids []interface{}, held int64) ([]models.ValidTransfer, error) {
+	if len(pids) == 0 {
+		return nil, models.ErrInvalidUnits
+	}
+
+	args := append([]interface{}{source}, pids...)
+	var res []models.ValidTransfer
+	err := mysequel.QueryToStructs(&res, tx, queries.INVALID_TRANSFERS(placeholders(len(pids))), append(args, held)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pids) != len(res) {
+		return nil, models.ErrInvalidUnits
+	}
+
+	return res, nil
+}
+
+// moveUnits closes the current stock legs of the units on the date given and
+// opens new ones under the movement document
+func moveUnits(tx *sql.Tx, did int64, date time.Time, res []models.ValidTransfer) error {
+	var pids []interface{}
+	for _, shentry := range res {
+		_, err := mysequel.Insert(mysequel.Table{
+			TableName: "stock_history",
+			Columns:   []string{"document_id", "model_id", "primary_id", "secondary_id", "price", "date_in", "date_out"},
+			Vals:      []interface{}{shentry.DocumentID, shentry.ModelID, shentry.PrimaryID, shentry.SecondaryID, shentry.Price, shentry.Date.Format("2006-01-02 15:04:05"), date.Format("2006-01-02 15:04:05")},
+			Tx:        tx,
+		})
+		if err != nil {
+			return err
+		}
+		pids = append(pids, shentry.PrimaryID)
+	}
+
+	_, err := tx.Exec(fmt.Sprintf("DELETE FROM main_stock WHERE primary_id IN (%s)", placeholders(len(pids))), pids...)
+	if err != nil {
+		return err
+	}
+
 	for _, shentry := range res {
 		_, err := mysequel.Insert(mysequel.Table{
 			TableName: "main_stock",
@@ -253,12 +444,11 @@ func (m *Warehouse) Movement(form url.Values) (int64, error) {
 			Tx:        tx,
 		})
 		if err != nil {
-			tx.Rollback()
-			return 0, err
+			return err
 		}
 	}
 
-	return did, nil
+	return nil
 }
 
 func (m *Warehouse) GoodsIn(form url.Values) (int64, error) {
@@ -274,20 +464,113 @@ func (m *Warehouse) GoodsIn(form url.Values) (int64, error) {
 		_ = tx.Commit()
 	}()
 
+	id, err := m.goodsIn(tx, form)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// goodsIn records a goods-in within the transaction given
+func (m *Warehouse) goodsIn(tx *sql.Tx, form url.Values) (int64, error) {
 	var goodsInItems []models.GoodsInItem
 	json.Unmarshal([]byte(form.Get("goods")), &goodsInItems)
 
+	documentType := form.Get("document_type")
+	err := documentTypeAllows(tx, documentType, "inbound", form.Get("from_warehouse_id"), form.Get("warehouse_id"))
+	if err != nil {
+		return 0, err
+	}
+
+	date, err := documentDate(tx, form.Get("date"))
+	if err != nil {
+		return 0, err
+	}
+
+	supplier := form.Get("supplier_id")
+	po := form.Get("purchase_order_id")
+	if po != "" {
+		var warehouseID string
+		err = tx.QueryRow(queries.PURCHASE_ORDER_HEADER, po).Scan(&supplier, &warehouseID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = models.ErrNoRecord
+			return 0, err
+		} else if err != nil {
+			return 0, err
+		}
+		if warehouseID != form.Get("warehouse_id") {
+			err = models.ErrPurchaseOrderMismatch
+			return 0, err
+		}
+	}
+
+	number, err := documentNumber(tx, documentType, form.Get("warehouse_id"), date)
+	if err != nil {
+		return 0, err
+	}
+
 	id, err := mysequel.Insert(mysequel.Table{
 		TableName: "document",
-		Columns:   []string{"document_type_id", "warehouse_id", "from_warehouse_id", "date"},
-		Vals:      []interface{}{1, form.Get("warehouse_id"), form.Get("from_warehouse_id"), time.Now().Format("2006-01-02 15:04:05")},
+		Columns:   []string{"document_type_id", "warehouse_id", "from_warehouse_id", "date", "number", "supplier_id"},
+		Vals:      []interface{}{documentType, form.Get("warehouse_id"), form.Get("from_warehouse_id"), date.Format("2006-01-02 15:04:05"), number, supplier},
 		Tx:        tx,
 	})
 	if err != nil {
-		tx.Rollback()
 		return 0, err
 	}
 
+	// Prices filled in from the purchase order or the price list are already
+	// in local currency, only those given with the goods are converted
+	local := make([]bool, len(goodsInItems))
+	for i, item := range goodsInItems {
+		local[i] = item.Price == ""
+	}
+
+	if po != "" {
+		err = m.receivePurchaseOrder(tx, po, id, goodsInItems)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Units without a price take the cost from the price list, and a unit
+	// priced by neither is refused rather than stocked without a price
+	for i, item := range goodsInItems {
+		if item.Price != "" {
+			continue
+		}
+		err = tx.QueryRow(queries.ACTIVE_COST_PRICE, item.Model, date.Format("2006-01-02")).Scan(&goodsInItems[i].Price)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = models.ErrInvalidCost
+			return 0, err
+		} else if err != nil {
+			return 0, err
+		}
+	}
+
+	if form.Get("currency") != "" || form.Get("freight") != "" || form.Get("duty") != "" || form.Get("clearing") != "" {
+		var costs []models.UnitCost
+		costs, err = landedCosts(form, goodsInItems, local)
+		if err != nil {
+			return 0, err
+		}
+
+		for i, c := range costs {
+			goodsInItems[i].Price = strconv.FormatFloat(math.Round(c.LandedCost), 'f', 0, 64)
+
+			_, err = mysequel.Insert(mysequel.Table{
+				TableName: "unit_cost",
+				Columns:   []string{"document_id", "primary_id", "currency", "exchange_rate", "foreign_price", "local_price", "freight", "duty", "clearing", "landed_cost"},
+				Vals:      []interface{}{id, c.PrimaryID, c.Currency, c.ExchangeRate, c.ForeignPrice, c.LocalPrice, c.Freight, c.Duty, c.Clearing, c.LandedCost},
+				Tx:        tx,
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
 	for _, item := range goodsInItems {
 		_, err := mysequel.Insert(mysequel.Table{
 			TableName: "main_stock",
@@ -296,10 +579,335 @@ func (m *Warehouse) GoodsIn(form url.Values) (int64, error) {
 			Tx:        tx,
 		})
 		if err != nil {
-			tx.Rollback()
 			return 0, err
 		}
 	}
 
 	return id, nil
 }
+
+// Sale removes sold units from the stock of a warehouse and records the buyer
+func (m *Warehouse) Sale(form url.Values, user string) (int64, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		_ = tx.Commit()
+	}()
+
+	var saleItems []models.SaleGoods
+	err = json.Unmarshal([]byte(form.Get("goods")), &saleItems)
+	if err != nil {
+		err = models.ErrInvalidGoods
+		return 0, err
+	}
+	if len(saleItems) == 0 {
+		err = models.ErrInvalidUnits
+		return 0, err
+	}
+	for _, item := range saleItems {
+		if price, perr := strconv.ParseFloat(item.Price, 64); perr != nil || price < 0 {
+			err = models.ErrInvalidGoods
+			return 0, err
+		}
+	}
+
+	err = documentTypeAllows(tx, form.Get("document_type"), "outbound", form.Get("warehouse_id"), "")
+	if err != nil {
+		return 0, err
+	}
+
+	salePrices := make(map[string]string)
+	args := []interface{}{form.Get("warehouse_id")}
+	for _, item := range saleItems {
+		salePrices[item.PrimaryNumber] = item.Price
+		args = append(args, item.PrimaryNumber)
+	}
+
+	var res []models.ValidTransfer
+	err = mysequel.QueryToStructs(&res, tx, queries.INVALID_TRANSFERS(placeholders(len(saleItems))), append(args, 0)...)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(saleItems) != len(res) {
+		err = models.ErrInvalidUnits
+		return 0, err
+	}
+
+	err = m.checkReservations(tx, user, form.Get("customer_id"), "0", args[1:])
+	if err != nil {
+		return 0, err
+	}
+
+	date, err := documentDate(tx, form.Get("date"))
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkLegDates(res, date)
+	if err != nil {
+		return 0, err
+	}
+
+	number, err := documentNumber(tx, form.Get("document_type"), form.Get("warehouse_id"), date)
+	if err != nil {
+		return 0, err
+	}
+
+	did, err := mysequel.Insert(mysequel.Table{
+		TableName: "document",
+		Columns:   []string{"document_type_id", "warehouse_id", "from_warehouse_id", "date", "number"},
+		Vals:      []interface{}{form.Get("document_type"), "", form.Get("warehouse_id"), date.Format("2006-01-02 15:04:05"), number},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = mysequel.Insert(mysequel.Table{
+		TableName: "sale",
+		Columns:   []string{"document_id", "customer_id", "invoice_number", "finance_company"},
+		Vals:      []interface{}{did, form.Get("customer_id"), form.Get("invoice_number"), form.Get("finance_company")},
+		Tx:        tx,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, shentry := range res {
+		_, err = mysequel.Insert(mysequel.Table{
+			TableName: "stock_history",
+			Columns:   []string{"document_id", "model_id", "primary_id", "secondary_id", "price", "date_in", "date_out"},
+			Vals:      []interface{}{shentry.DocumentID, shentry.ModelID, shentry.PrimaryID, shentry.SecondaryID, shentry.Price, shentry.Date.Format("2006-01-02 15:04:05"), date.Format("2006-01-02 15:04:05")},
+			Tx:        tx,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = mysequel.Insert(mysequel.Table{
+			TableName: "sale_item",
+			Columns:   []string{"document_id", "model_id", "primary_id", "secondary_id", "price"},
+			Vals:      []interface{}{did, shentry.ModelID, shentry.PrimaryID, shentry.SecondaryID, salePrices[shentry.PrimaryID]},
+			Tx:        tx,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM main_stock WHERE primary_id IN (%s)", placeholders(len(saleItems))), args[1:]...)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("UPDATE reservation SET released_at = NOW() WHERE released_at IS NULL AND primary_id IN (%s)", placeholders(len(saleItems))), args[1:]...)
+	if err != nil {
+		return 0, err
+	}
+
+	return did, nil
+}
+
+// SaleDetails returns a sale document with the units sold
+func (m *Warehouse) SaleDetails(id int) (models.Sale, error) {
+	var s models.Sale
+	err := m.DB.QueryRow(queries.SALE, id).Scan(&s.DocumentID, &s.Date, &s.WarehouseID, &s.Warehouse, &s.InvoiceNumber, &s.CustomerID, &s.CustomerName, &s.CustomerNIC, &s.CustomerContact, &s.CustomerAddress, &s.FinanceCompany)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Sale{}, models.ErrNoRecord
+		}
+		return models.Sale{}, err
+	}
+
+	err = mysequel.QueryToStructs(&s.Units, m.DB, queries.SALE_UNITS, id)
+	if err != nil {
+		return models.Sale{}, err
+	}
+
+	return s, nil
+}
+
+// landedCosts converts the goods-in prices from the document currency at its
+// exchange rate and spreads the freight, duty and clearing totals, given in
+// local currency, across the units in proportion to their local price. Prices
+// marked local are taken as they are and carry no foreign price.
+func landedCosts(form url.Values, items []models.GoodsInItem, local []bool) ([]models.UnitCost, error) {
+	amount := func(name string, def float64) (float64, error) {
+		v := form.Get(name)
+		if v == "" {
+			return def, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			return 0, models.ErrInvalidCost
+		}
+		return f, nil
+	}
+
+	rate, err := amount("exchange_rate", 1)
+	if err != nil {
+		return nil, err
+	}
+	if rate == 0 || (form.Get("currency") != "" && form.Get("exchange_rate") == "") {
+		return nil, models.ErrInvalidCost
+	}
+
+	var components [3]float64
+	for i, name := range []string{"freight", "duty", "clearing"} {
+		components[i], err = amount(name, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	costs := make([]models.UnitCost, len(items))
+	total := 0.0
+	for i, item := range items {
+		price, err := strconv.ParseFloat(item.Price, 64)
+		if err != nil {
+			return nil, models.ErrInvalidCost
+		}
+
+		costs[i] = models.UnitCost{
+			PrimaryID:    item.PrimaryNumber,
+			Currency:     form.Get("currency"),
+			ExchangeRate: rate,
+			ForeignPrice: price,
+			LocalPrice:   price * rate,
+		}
+		if local[i] {
+			costs[i].ForeignPrice = 0
+			costs[i].LocalPrice = price
+		}
+		total += costs[i].LocalPrice
+	}
+
+	for i := range costs {
+		share := 1 / float64(len(costs))
+		if total > 0 {
+			share = costs[i].LocalPrice / total
+		}
+
+		costs[i].Freight = components[0] * share
+		costs[i].Duty = components[1] * share
+		costs[i].Clearing = components[2] * share
+		costs[i].LandedCost = costs[i].LocalPrice + costs[i].Freight + costs[i].Duty + costs[i].Clearing
+	}
+
+	return costs, nil
+}
+
+// receivePurchaseOrder records goods-in items against the purchase order lines
+// and fills in the agreed price for items received without one
+func (m *Warehouse) receivePurchaseOrder(tx *sql.Tx, po string, did int64, items []models.GoodsInItem) error {
+	var lines []models.PurchaseOrderLine
+	err := mysequel.QueryToStructs(&lines, tx, queries.PURCHASE_ORDER_LINES_FOR_UPDATE, po)
+	if err != nil {
+		return err
+	}
+
+	// each unit takes the first line of its model with quantity left
+	received := make([]int, len(lines))
+	for i, item := range items {
+		found, filled := false, false
+		for j, line := range lines {
+			if line.ModelID != item.Model {
+				continue
+			}
+			found = true
+			if line.Received+received[j] >= line.Quantity {
+				continue
+			}
+			filled = true
+			received[j]++
+			if item.Price == "" {
+				items[i].Price = line.Price
+			}
+			break
+		}
+		if !found {
+			return models.ErrPurchaseOrderMismatch
+		}
+		if !filled {
+			return models.ErrPurchaseOrderExceeded
+		}
+	}
+
+	for j, line := range lines {
+		count := received[j]
+		if count == 0 {
+			continue
+		}
+
+		_, err = tx.Exec("UPDATE purchase_order_item SET received = received + ? WHERE id = ?", count, line.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = mysequel.Insert(mysequel.Table{
+		TableName: "purchase_order_receipt",
+		Columns:   []string{"purchase_order_id", "document_id"},
+		Vals:      []interface{}{po, did},
+		Tx:        tx,
+	})
+	return err
+}
+
+// checkReservations fails when any of the units is held for someone else. A
+// hold for a customer or a warehouse only lets the units go to that customer
+// or warehouse, and a hold for neither only lets its creator use them.
+func (m *Warehouse) checkReservations(tx *sql.Tx, user, customerID, warehouseID string, pids []interface{}) error {
+	if len(pids) == 0 {
+		return nil
+	}
+
+	args := append(append([]interface{}{}, pids...), customerID, warehouseID, user)
+	rows, err := tx.Query(queries.RESERVED_BY_OTHERS(placeholders(len(pids))), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return models.ErrReserved
+	}
+	return rows.Err()
+}
+
+// LabelUnit returns the label details of an in stock unit
+func (m *Warehouse) LabelUnit(primaryID string) (models.LabelUnit, error) {
+	var u models.LabelUnit
+	err := m.DB.QueryRow(queries.LABEL_UNIT, primaryID).Scan(&u.PrimaryID, &u.SecondaryID, &u.Model)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LabelUnit{}, models.ErrNoRecord
+		}
+		return models.LabelUnit{}, err
+	}
+
+	return u, nil
+}
+
+// DocumentLabelUnits returns the label details of the units a document brought in
+func (m *Warehouse) DocumentLabelUnits(id int) ([]models.LabelUnit, error) {
+	var res []models.LabelUnit
+	err := mysequel.QueryToStructs(&res, m.DB, queries.DOCUMENT_LABEL_UNITS, id, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// placeholders returns n comma separated bind parameters for an IN clause
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"math"
+	"net/url"
+	"testing"
+
+	"github.com/ssrdive/basara/pkg/models"
+)
+
+func TestLandedCosts(t *testing.T) {
+	items := []models.GoodsInItem{
+		{PrimaryNumber: "A", Price: "100"},
+		{PrimaryNumber: "B", Price: "300"},
+	}
+
+	tests := []struct {
+		name    string
+		form    url.Values
+		local   []bool
+		foreign []float64
+		landed  []float64
+	}{
+		{
+			"local currency with costs",
+			url.Values{"freight": {"40"}, "duty": {"20"}, "clearing": {"0"}},
+			[]bool{false, false},
+			[]float64{100, 300},
+			[]float64{115, 345},
+		},
+		{
+			"foreign currency",
+			url.Values{"currency": {"USD"}, "exchange_rate": {"2"}, "freight": {"80"}},
+			[]bool{false, false},
+			[]float64{100, 300},
+			[]float64{220, 660},
+		},
+		{
+			"defaulted prices are not converted",
+			url.Values{"currency": {"USD"}, "exchange_rate": {"3"}, "freight": {"100"}},
+			[]bool{true, false},
+			[]float64{0, 300},
+			[]float64{110, 990},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			costs, err := landedCosts(tt.form, items, tt.local)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, c := range costs {
+				if c.PrimaryID != items[i].PrimaryNumber {
+					t.Errorf("unit %d primary id = %q", i, c.PrimaryID)
+				}
+				if c.ForeignPrice != tt.foreign[i] {
+					t.Errorf("unit %d foreign price = %v, want %v", i, c.ForeignPrice, tt.foreign[i])
+				}
+				if math.Abs(c.LandedCost-tt.landed[i]) > 1e-9 {
+					t.Errorf("unit %d landed cost = %v, want %v", i, c.LandedCost, tt.landed[i])
+				}
+				if sum := c.LocalPrice + c.Freight + c.Duty + c.Clearing; math.Abs(sum-c.LandedCost) > 1e-9 {
+					t.Errorf("unit %d components add up to %v, landed cost is %v", i, sum, c.LandedCost)
+				}
+			}
+		})
+	}
+}
+
+func TestLandedCostsZeroPrices(t *testing.T) {
+	items := []models.GoodsInItem{{PrimaryNumber: "A", Price: "0"}, {PrimaryNumber: "B", Price: "0"}}
+	costs, err := landedCosts(url.Values{"freight": {"50"}}, items, []bool{false, false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range costs {
+		if c.LandedCost != 25 {
+			t.Errorf("unit %d landed cost = %v, want an even share of 25", i, c.LandedCost)
+		}
+	}
+}
+
+func TestLandedCostsInvalid(t *testing.T) {
+	items := []models.GoodsInItem{{PrimaryNumber: "A", Price: "100"}}
+	for name, form := range map[string]url.Values{
+		"currency without rate": {"currency": {"USD"}},
+		"zero rate":             {"currency": {"USD"}, "exchange_rate": {"0"}},
+		"negative freight":      {"freight": {"-1"}},
+		"non-numeric duty":      {"duty": {"ten"}},
+	} {
+		if _, err := landedCosts(form, items, []bool{false}); err != models.ErrInvalidCost {
+			t.Errorf("%s: got %v, want ErrInvalidCost", name, err)
+		}
+	}
+
+	missing := []models.GoodsInItem{{PrimaryNumber: "A"}}
+	if _, err := landedCosts(url.Values{"freight": {"10"}}, missing, []bool{true}); err != models.ErrInvalidCost {
+		t.Errorf("missing price: got %v, want ErrInvalidCost", err)
+	}
+}
@@ -0,0 +1,26 @@
+-- Document numbering series. A document type has at most one series per
+-- issuing warehouse, and each series keeps one counter per year. The keys
+-- are what lets the counter be taken with INSERT ... ON DUPLICATE KEY
+-- UPDATE, so numbers stay unique under concurrent documents.
+CREATE TABLE IF NOT EXISTS document_series (
+	id INT NOT NULL AUTO_INCREMENT,
+	document_type_id INT NOT NULL,
+	warehouse_id INT NOT NULL,
+	prefix VARCHAR(16) NOT NULL,
+	padding INT NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY document_series_type_warehouse (document_type_id, warehouse_id),
+	FOREIGN KEY (document_type_id) REFERENCES document_type (id),
+	FOREIGN KEY (warehouse_id) REFERENCES warehouse (id)
+);
+
+CREATE TABLE IF NOT EXISTS document_series_counter (
+	document_series_id INT NOT NULL,
+	year INT NOT NULL,
+	last_number INT NOT NULL,
+	PRIMARY KEY (document_series_id, year),
+	FOREIGN KEY (document_series_id) REFERENCES document_series (id)
+);
+
+ALTER TABLE document ADD COLUMN number VARCHAR(64) NULL;
+CREATE INDEX document_number ON document (number);
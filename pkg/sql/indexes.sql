@@ -0,0 +1,11 @@
+-- Indexes used by the unit search (/search) and its include_history branches
+CREATE INDEX main_stock_primary_id ON main_stock (primary_id);
+CREATE INDEX main_stock_secondary_id ON main_stock (secondary_id);
+CREATE INDEX main_stock_model_id ON main_stock (model_id);
+CREATE INDEX stock_history_primary_id_date_out ON stock_history (primary_id, date_out);
+CREATE INDEX stock_history_secondary_id ON stock_history (secondary_id);
+CREATE INDEX sale_item_primary_id ON sale_item (primary_id);
+CREATE INDEX sale_item_secondary_id ON sale_item (secondary_id);
+CREATE INDEX document_warehouse_id ON document (warehouse_id);
+CREATE INDEX document_from_warehouse_id ON document (from_warehouse_id);
+CREATE INDEX reservation_primary_id ON reservation (primary_id, released_at, expires_at);
@@ -1,6 +1,9 @@
 package queries
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const ALL_MODELS = `
 	SELECT * FROM model`
@@ -9,37 +12,102 @@ const ALL_WAREHOUSES = `
 	SELECT W.id, WT.name as warehouse_type, W.name, W.address, W.contact FROM warehouse W LEFT JOIN warehouse_type WT ON WT.id = W.warehouse_type_id
 `
 
-const SEC_MODEL = `
-	SELECT M.name, MS.secondary_id
+const lookup = `
+	SELECT MS.primary_id, MS.secondary_id, M.name AS model, W.id AS warehouse_id, W.name AS warehouse
 	FROM main_stock MS
 	LEFT JOIN model M ON M.id = MS.model_id
-	WHERE primary_id = ?
+	LEFT JOIN document DD ON DD.id = MS.document_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
 `
 
+const LOOKUP_BY_PRIMARY = lookup + `WHERE MS.primary_id = ?`
+
+const LOOKUP_BY_SECONDARY = lookup + `WHERE MS.secondary_id = ?`
+
+func LOOKUP_BATCH(numbers string) string {
+	return fmt.Sprintf(lookup+`WHERE MS.primary_id IN (%s) OR MS.secondary_id IN (%s)`, numbers, numbers)
+}
+
+// INVALID_TRANSFERS takes the warehouse, the primary ids and a document whose
+// approval may hold the units. Units held by any other pending approval are
+// left out.
 func INVALID_TRANSFERS(pids string) string {
-	return fmt.Sprintf("SELECT MS.*, DD.date FROM main_stock MS LEFT JOIN document DD ON MS.document_id = DD.id WHERE DD.warehouse_id = ? AND MS.primary_id IN (%s)", pids)
+	return fmt.Sprintf("SELECT MS.*, DD.date FROM main_stock MS LEFT JOIN document DD ON MS.document_id = DD.id WHERE DD.warehouse_id = ? AND MS.primary_id IN (%s) AND NOT EXISTS (SELECT 1 FROM document_pending_item PI JOIN document_approval DA ON DA.document_id = PI.document_id WHERE PI.primary_id = MS.primary_id AND DA.status = 'pending' AND DA.document_id <> ?)", pids)
 }
 
+// firstInDate is the date a unit in main_stock MS first came into stock,
+// which survives transfers unlike the date of its current document DD
+const firstInDate = `COALESCE((SELECT MIN(FH.date_in) FROM stock_history FH WHERE FH.primary_id = MS.primary_id), DD.date)`
+
 const WAREHOUSE_STOCK = `
-	SELECT MS.document_id, MS.primary_id, MS.secondary_id, DATEDIFF(NOW(), DD.date) as in_stock_for, MS.price, M.name as model, DD.date, DDT.name as delivery_document_type 
+	SELECT MS.document_id, MS.primary_id, MS.secondary_id, DATEDIFF(NOW(), DD.date) as in_stock_for, DATEDIFF(NOW(), ` + firstInDate + `) AS total_age, MS.price, M.name as model, DD.date, DDT.name as delivery_document_type, COALESCE(R.expires_at, '') AS reserved_until 
 	FROM main_stock MS 
 	LEFT JOIN model M  ON MS.model_id = M.id 
 	LEFT JOIN document DD ON MS.document_id = DD.id 
 	LEFT JOIN document_type DDT ON DD.document_type_id = DDT.id 
+	LEFT JOIN reservation R ON R.primary_id = MS.primary_id AND R.released_at IS NULL AND R.expires_at > NOW()
 	WHERE DD.warehouse_id = ?
 `
 
-const SEARCH = `
-	SELECT MS.document_id, M.name AS model, W.name as warehouse, MS.primary_id, MS.secondary_id, MS.price, W.id as warehouse_id 
+const SEARCH_IN_STOCK = `
+	SELECT MS.document_id, M.name AS model, W.name as warehouse, MS.primary_id, MS.secondary_id, MS.price, W.id as warehouse_id, IF(R.id IS NULL, 'in_stock', 'reserved') AS status, '' AS customer, COALESCE(R.expires_at, '') AS reserved_until, DATEDIFF(NOW(), DD.date) AS in_stock_for, DATEDIFF(NOW(), ` + firstInDate + `) AS total_age
+	` + SEARCH_IN_STOCK_FROM
+
+const SEARCH_IN_STOCK_FROM = `
 	FROM main_stock MS 
 	LEFT JOIN model M ON MS.model_id = M.id 
 	LEFT JOIN document DD ON MS.document_id = DD.id 
 	LEFT JOIN warehouse W ON DD.warehouse_id = W.id 
-	WHERE CONCAT(MS.document_id, M.name, W.name, MS.primary_id, MS.secondary_id) LIKE ?
+	LEFT JOIN reservation R ON R.primary_id = MS.primary_id AND R.released_at IS NULL AND R.expires_at > NOW()
 `
 
+const SEARCH_SOLD = `
+	SELECT SI.document_id, M.name AS model, W.name as warehouse, SI.primary_id, SI.secondary_id, SI.price, W.id as warehouse_id, 'sold' AS status, C.name AS customer, '' AS reserved_until, 0 AS in_stock_for, DATEDIFF(DD.date, (SELECT MIN(FH.date_in) FROM stock_history FH WHERE FH.primary_id = SI.primary_id)) AS total_age
+	` + SEARCH_SOLD_FROM
+
+const SEARCH_SOLD_FROM = `
+	FROM sale_item SI
+	LEFT JOIN model M ON SI.model_id = M.id
+	LEFT JOIN sale S ON S.document_id = SI.document_id
+	LEFT JOIN customer C ON C.id = S.customer_id
+	LEFT JOIN document DD ON SI.document_id = DD.id
+	LEFT JOIN warehouse W ON DD.from_warehouse_id = W.id
+`
+
+const SEARCH_MOVED = `
+	SELECT SH.document_id, M.name AS model, W.name as warehouse, SH.primary_id, SH.secondary_id, SH.price, W.id as warehouse_id, 'moved' AS status, '' AS customer, '' AS reserved_until, DATEDIFF(SH.date_out, SH.date_in) AS in_stock_for, DATEDIFF(SH.date_out, (SELECT MIN(FH.date_in) FROM stock_history FH WHERE FH.primary_id = SH.primary_id)) AS total_age
+	` + SEARCH_MOVED_FROM
+
+const SEARCH_MOVED_FROM = `
+	FROM stock_history SH
+	LEFT JOIN model M ON SH.model_id = M.id
+	LEFT JOIN document DD ON SH.document_id = DD.id
+	LEFT JOIN warehouse W ON DD.warehouse_id = W.id
+`
+
+// SEARCH_MOVED_NOT_SOLD leaves out the leg a sale closed, which is already
+// listed as sold: the last leg of a unit that has a sale item.
+const SEARCH_MOVED_NOT_SOLD = `NOT (EXISTS (SELECT 1 FROM sale_item SX WHERE SX.primary_id = SH.primary_id) AND SH.date_out = (SELECT MAX(LH.date_out) FROM stock_history LH WHERE LH.primary_id = SH.primary_id))`
+
+func SEARCH(sources []string, order string) string {
+	return fmt.Sprintf(`
+	SELECT U.document_id, U.model, U.warehouse, U.primary_id, U.secondary_id, U.price, U.warehouse_id, U.status, U.customer, U.reserved_until, U.in_stock_for, U.total_age
+	FROM (%s) U
+	ORDER BY %s
+	LIMIT ? OFFSET ?
+`, strings.Join(sources, " UNION ALL "), order)
+}
+
+func SEARCH_COUNT(sources []string) string {
+	counts := make([]string, len(sources))
+	for i, source := range sources {
+		counts[i] = "(SELECT COUNT(*) " + source + ")"
+	}
+	return "SELECT " + strings.Join(counts, " + ")
+}
+
 const AGE_WISE_SEARCH = `
-	SELECT MS.document_id, MS.primary_id, MS.secondary_id, DATEDIFF(NOW(), DD.date) as in_stock_for, MS.price, M.name as model, DD.date, DDT.name as delivery_document_type 
+	SELECT MS.document_id, MS.primary_id, MS.secondary_id, DATEDIFF(NOW(), DD.date) as in_stock_for, DATEDIFF(NOW(), ` + firstInDate + `) AS total_age, MS.price, M.name as model, DD.date, DDT.name as delivery_document_type 
 	FROM main_stock MS 
 	LEFT JOIN model M  ON MS.model_id = M.id 
 	LEFT JOIN document DD ON MS.document_id = DD.id 
@@ -48,16 +116,17 @@ const AGE_WISE_SEARCH = `
 `
 
 const RECENT_DOCUMENTS = `
-	SELECT DD.id AS document_id, DDT.name AS delivery_document_type, DD.date, W.id AS to_warehouse_id, W.name AS to_warehouse, FW.id AS from_warehouse_id, FW.name AS from_warehouse
+	SELECT DD.id AS document_id, COALESCE(DD.number, '') AS number, DDT.name AS delivery_document_type, DD.date, W.id AS to_warehouse_id, W.name AS to_warehouse, FW.id AS from_warehouse_id, FW.name AS from_warehouse, COALESCE(NULLIF(DA.status, 'approved'), 'posted') AS status
 	FROM document DD 
 	LEFT JOIN document_type DDT ON DD.document_type_id = DDT.id 
 	LEFT JOIN warehouse W ON DD.warehouse_id = W.id 
 	LEFT JOIN warehouse FW ON DD.from_warehouse_id = FW.id 
+	LEFT JOIN document_approval DA ON DA.document_id = DD.id
 	ORDER BY DD.date DESC LIMIT 5 OFFSET 0
 `
 
 const HISTORY = `
-	SELECT SH.document_id, SH.primary_id, SH.secondary_id, DATEDIFF(date_in, date_out) as in_stock_for, SH.price, SH.date_in, SH.date_out, DDT.name AS delivery_document_type, W.name AS warehouse, W.id as warehouse_id, M.name AS model 
+	SELECT SH.document_id, SH.primary_id, SH.secondary_id, DATEDIFF(SH.date_out, SH.date_in) as in_stock_for, SH.price, SH.date_in, SH.date_out, DDT.name AS delivery_document_type, W.name AS warehouse, W.id as warehouse_id, M.name AS model 
 	FROM stock_history SH 
 	LEFT JOIN document DD ON SH.document_id = DD.id 
 	LEFT JOIN document_type DDT ON DD.document_type_id = DDT.id 
@@ -83,3 +152,744 @@ const ALL_USERS = `
 	SELECT username, name, type
 	FROM user
 `
+
+const SALE = `
+	SELECT DD.id AS document_id, DD.date, W.id AS warehouse_id, W.name AS warehouse, S.invoice_number, C.id AS customer_id, C.name AS customer_name, C.nic AS customer_nic, C.contact AS customer_contact, C.address AS customer_address, COALESCE(S.finance_company, '') AS finance_company
+	FROM sale S
+	LEFT JOIN customer C ON C.id = S.customer_id
+	LEFT JOIN document DD ON DD.id = S.document_id
+	LEFT JOIN warehouse W ON W.id = DD.from_warehouse_id
+	WHERE S.document_id = ?
+`
+
+const SALE_UNITS = `
+	SELECT SI.primary_id, SI.secondary_id, M.name AS model, SI.price
+	FROM sale_item SI
+	LEFT JOIN model M ON M.id = SI.model_id
+	WHERE SI.document_id = ?
+`
+
+const CUSTOMER = `
+	SELECT id, name, nic, contact, address
+	FROM customer
+	WHERE id = ?
+`
+
+const SEARCH_CUSTOMERS = `
+	SELECT id, name, nic, contact, address
+	FROM customer
+	WHERE CONCAT(name, nic, contact) LIKE ?
+	ORDER BY name ASC
+`
+
+const CUSTOMER_PURCHASES = `
+	SELECT DD.id AS document_id, DD.date, S.invoice_number, W.name AS warehouse, SI.primary_id, SI.secondary_id, M.name AS model, SI.price
+	FROM sale S
+	LEFT JOIN sale_item SI ON SI.document_id = S.document_id
+	LEFT JOIN document DD ON DD.id = S.document_id
+	LEFT JOIN warehouse W ON W.id = DD.from_warehouse_id
+	LEFT JOIN model M ON M.id = SI.model_id
+	WHERE S.customer_id = ?
+	ORDER BY DD.date DESC
+`
+
+const ALL_SUPPLIERS = `
+	SELECT id, name, contact, address
+	FROM supplier
+	ORDER BY name ASC
+`
+
+const PURCHASE_ORDER = `
+	SELECT PO.id, PO.date, S.id AS supplier_id, S.name AS supplier, W.id AS warehouse_id, W.name AS warehouse
+	FROM purchase_order PO
+	LEFT JOIN supplier S ON S.id = PO.supplier_id
+	LEFT JOIN warehouse W ON W.id = PO.warehouse_id
+	WHERE PO.id = ?
+`
+
+const PURCHASE_ORDER_ITEMS = `
+	SELECT POI.model_id, M.name AS model, POI.quantity, POI.received, POI.quantity - POI.received AS outstanding, POI.price
+	FROM purchase_order_item POI
+	LEFT JOIN model M ON M.id = POI.model_id
+	WHERE POI.purchase_order_id = ?
+`
+
+const PURCHASE_ORDER_HEADER = `
+	SELECT supplier_id, warehouse_id
+	FROM purchase_order
+	WHERE id = ?
+`
+
+const PURCHASE_ORDER_LINES_FOR_UPDATE = `
+	SELECT id, model_id, quantity, received, price
+	FROM purchase_order_item
+	WHERE purchase_order_id = ?
+	ORDER BY id ASC
+	FOR UPDATE
+`
+
+const OUTSTANDING_PURCHASE_ITEMS = `
+	SELECT PO.id AS purchase_order_id, PO.date, S.name AS supplier, W.name AS warehouse, M.name AS model, POI.quantity, POI.received, POI.quantity - POI.received AS outstanding
+	FROM purchase_order_item POI
+	LEFT JOIN purchase_order PO ON PO.id = POI.purchase_order_id
+	LEFT JOIN supplier S ON S.id = PO.supplier_id
+	LEFT JOIN warehouse W ON W.id = PO.warehouse_id
+	LEFT JOIN model M ON M.id = POI.model_id
+	WHERE POI.received < POI.quantity
+	ORDER BY PO.date ASC
+`
+
+// RESERVED_BY_OTHERS takes the primary ids, then the customer, warehouse and
+// user the units are moved or sold for. The holder of a reservation and the
+// user who made it may both use the unit.
+func RESERVED_BY_OTHERS(pids string) string {
+	return fmt.Sprintf(`
+	SELECT primary_id
+	FROM reservation
+	WHERE released_at IS NULL AND expires_at > NOW() AND primary_id IN (%s)
+	AND NOT (
+		(customer_id IS NOT NULL AND customer_id = ?)
+		OR (customer_id IS NULL AND warehouse_id IS NOT NULL AND warehouse_id = ?)
+		OR created_by = ?
+	)
+`, pids)
+}
+
+const ACTIVE_RESERVATION_COUNT = `
+	SELECT COUNT(*)
+	FROM reservation
+	WHERE released_at IS NULL AND expires_at > NOW() AND primary_id = ?
+`
+
+// UNIT_IN_STOCK_COUNT locks the stock entry of the unit so reservations of
+// it are made one at a time
+const UNIT_IN_STOCK_COUNT = `
+	SELECT COUNT(*)
+	FROM main_stock
+	WHERE primary_id = ?
+	FOR UPDATE
+`
+
+const ACTIVE_RESERVATIONS = `
+	SELECT R.id, R.primary_id, MS.secondary_id, M.name AS model, W.name AS warehouse, COALESCE(R.customer_id, 0) AS customer_id, COALESCE(C.name, '') AS customer, COALESCE(RW.name, '') AS reserved_for, R.expires_at, R.created_by
+	FROM reservation R
+	LEFT JOIN main_stock MS ON MS.primary_id = R.primary_id
+	LEFT JOIN model M ON M.id = MS.model_id
+	LEFT JOIN document DD ON DD.id = MS.document_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
+	LEFT JOIN customer C ON C.id = R.customer_id
+	LEFT JOIN warehouse RW ON RW.id = R.warehouse_id
+	WHERE R.released_at IS NULL AND R.expires_at > NOW()
+	ORDER BY R.expires_at ASC
+`
+
+// stockAsAt lists the units held at a point in time from the closed legs in
+// stock_history and the open legs in main_stock. It takes the point in time
+// as its three parameters.
+const stockAsAt = `
+	SELECT SH.document_id, SH.model_id, SH.primary_id, SH.secondary_id, SH.price, SH.date_in
+	FROM stock_history SH
+	WHERE SH.date_in < ? AND SH.date_out >= ?
+	UNION ALL
+	SELECT MS.document_id, MS.model_id, MS.primary_id, MS.secondary_id, MS.price, SD.date AS date_in
+	FROM main_stock MS
+	LEFT JOIN document SD ON SD.id = MS.document_id
+	WHERE SD.date < ?
+`
+
+const VALUATION_BY_WAREHOUSE = `
+	SELECT W.id AS warehouse_id, W.name AS warehouse, COUNT(*) AS count, COALESCE(SUM(U.price), 0) AS total, COALESCE(AVG(U.price), 0) AS average
+	FROM (` + stockAsAt + `) U
+	LEFT JOIN document DD ON DD.id = U.document_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
+	GROUP BY W.id, W.name
+	ORDER BY W.name ASC
+`
+
+const VALUATION_BY_MODEL = `
+	SELECT M.id AS model_id, M.name AS model, COUNT(*) AS count, COALESCE(SUM(U.price), 0) AS total, COALESCE(AVG(U.price), 0) AS average
+	FROM (` + stockAsAt + `) U
+	LEFT JOIN model M ON M.id = U.model_id
+	GROUP BY M.id, M.name
+	ORDER BY M.name ASC
+`
+
+const VALUATION_BY_WAREHOUSE_MODEL = `
+	SELECT W.id AS warehouse_id, W.name AS warehouse, M.id AS model_id, M.name AS model, COUNT(*) AS count, COALESCE(SUM(U.price), 0) AS total, COALESCE(AVG(U.price), 0) AS average
+	FROM (` + stockAsAt + `) U
+	LEFT JOIN document DD ON DD.id = U.document_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
+	LEFT JOIN model M ON M.id = U.model_id
+	GROUP BY W.id, W.name, M.id, M.name
+	ORDER BY W.name ASC, M.name ASC
+`
+
+const STOCK_AS_AT = `
+	SELECT U.document_id, U.primary_id, U.secondary_id, M.name AS model, U.price, W.id AS warehouse_id, W.name AS warehouse, U.date_in
+	FROM (` + stockAsAt + `) U
+	LEFT JOIN document DD ON DD.id = U.document_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
+	LEFT JOIN model M ON M.id = U.model_id
+	WHERE (? IS NULL OR DD.warehouse_id = ?)
+	ORDER BY W.name ASC, M.name ASC, U.primary_id ASC
+`
+
+const AGEING_UNITS = `
+	SELECT W.id AS warehouse_id, W.name AS warehouse, M.id AS model_id, M.name AS model, DATEDIFF(NOW(), DD.date) AS age, DATEDIFF(NOW(), ` + firstInDate + `) AS total_age, MS.price
+	FROM main_stock MS
+	LEFT JOIN model M ON MS.model_id = M.id
+	LEFT JOIN document DD ON MS.document_id = DD.id
+	LEFT JOIN warehouse W ON DD.warehouse_id = W.id
+	ORDER BY W.name ASC, M.name ASC
+`
+
+// stockLegs lists every stay of a unit in a warehouse, closed or open, with
+// the document that brought it there
+const stockLegs = `
+	SELECT document_id, model_id FROM stock_history
+	UNION ALL
+	SELECT document_id, model_id FROM main_stock
+`
+
+// MOVEMENT_EVENTS counts goods-in, transfers and sales per period. Each of
+// the three parts takes the period format, the start and the end.
+const MOVEMENT_EVENTS = `
+	SELECT E.period, E.warehouse_id, MAX(W.name) AS warehouse, E.model_id, MAX(M.name) AS model, E.kind, COUNT(*) AS count
+	FROM (
+		SELECT DATE_FORMAT(DD.date, ?) AS period, DD.warehouse_id, L.model_id, IF(DT.transfer, 'transfer_in', 'inflow') AS kind
+		FROM (` + stockLegs + `) L
+		LEFT JOIN document DD ON DD.id = L.document_id
+		LEFT JOIN document_type DT ON DT.id = DD.document_type_id
+		WHERE DD.date >= ? AND DD.date < ?
+		UNION ALL
+		SELECT DATE_FORMAT(DD.date, ?) AS period, DD.from_warehouse_id AS warehouse_id, L.model_id, 'transfer_out' AS kind
+		FROM (` + stockLegs + `) L
+		LEFT JOIN document DD ON DD.id = L.document_id
+		LEFT JOIN document_type DT ON DT.id = DD.document_type_id
+		WHERE DT.transfer = 1 AND DD.date >= ? AND DD.date < ?
+		UNION ALL
+		SELECT DATE_FORMAT(DD.date, ?) AS period, DD.from_warehouse_id AS warehouse_id, SI.model_id, 'outflow' AS kind
+		FROM sale_item SI
+		LEFT JOIN document DD ON DD.id = SI.document_id
+		WHERE DD.date >= ? AND DD.date < ?
+	) E
+	LEFT JOIN warehouse W ON W.id = E.warehouse_id
+	LEFT JOIN model M ON M.id = E.model_id
+	GROUP BY E.period, E.warehouse_id, E.model_id, E.kind
+	ORDER BY E.warehouse_id ASC, E.model_id ASC, E.period ASC
+`
+
+const SET_REORDER_LEVEL = `
+	INSERT INTO reorder_level (warehouse_id, model_id, min_quantity, max_quantity)
+	VALUES (?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE min_quantity = VALUES(min_quantity), max_quantity = VALUES(max_quantity)
+`
+
+const ALL_REORDER_LEVELS = `
+	SELECT RL.id, W.id AS warehouse_id, W.name AS warehouse, M.id AS model_id, M.name AS model, RL.min_quantity, RL.max_quantity
+	FROM reorder_level RL
+	LEFT JOIN warehouse W ON W.id = RL.warehouse_id
+	LEFT JOIN model M ON M.id = RL.model_id
+	ORDER BY W.name ASC, M.name ASC
+`
+
+// reorderOnHand counts the units of the model of a reorder level RL held
+// by its warehouse
+const reorderOnHand = `(SELECT COUNT(*) FROM main_stock MS LEFT JOIN document DD ON DD.id = MS.document_id WHERE DD.warehouse_id = RL.warehouse_id AND MS.model_id = RL.model_id)`
+
+const reorderLevels = `
+	SELECT W.id AS warehouse_id, W.name AS warehouse, M.id AS model_id, M.name AS model, RL.min_quantity, RL.max_quantity, RL.alerted_at, ` + reorderOnHand + ` AS on_hand
+	FROM reorder_level RL
+	LEFT JOIN warehouse W ON W.id = RL.warehouse_id
+	LEFT JOIN model M ON M.id = RL.model_id
+`
+
+const REORDER_SUGGESTIONS = `
+	SELECT R.warehouse_id, R.warehouse, R.model_id, R.model, R.min_quantity, R.max_quantity, R.on_hand, R.max_quantity - R.on_hand AS suggested
+	FROM (` + reorderLevels + `) R
+	WHERE R.on_hand < R.min_quantity
+	ORDER BY R.warehouse ASC, R.model ASC
+`
+
+// REORDER_ALERTS_DUE returns the suggestions no alert has been sent for since
+// the stock fell below the minimum
+const REORDER_ALERTS_DUE = `
+	SELECT R.warehouse_id, R.warehouse, R.model_id, R.model, R.min_quantity, R.max_quantity, R.on_hand, R.max_quantity - R.on_hand AS suggested
+	FROM (` + reorderLevels + `) R
+	WHERE R.on_hand < R.min_quantity AND R.alerted_at IS NULL
+	ORDER BY R.warehouse ASC, R.model ASC
+`
+
+const MARK_REORDER_ALERTED = `
+	UPDATE reorder_level
+	SET alerted_at = NOW()
+	WHERE warehouse_id = ? AND model_id = ?
+`
+
+const CLEAR_RECOVERED_REORDER_ALERTS = `
+	UPDATE reorder_level RL
+	SET RL.alerted_at = NULL
+	WHERE RL.alerted_at IS NOT NULL AND ` + reorderOnHand + ` >= RL.min_quantity
+`
+
+const LABEL_UNIT = `
+	SELECT MS.primary_id, MS.secondary_id, M.name AS model
+	FROM main_stock MS
+	LEFT JOIN model M ON M.id = MS.model_id
+	WHERE MS.primary_id = ?
+`
+
+const DOCUMENT_LABEL_UNITS = `
+	SELECT L.primary_id, L.secondary_id, M.name AS model
+	FROM (
+		SELECT primary_id, secondary_id, model_id FROM main_stock WHERE document_id = ?
+		UNION
+		SELECT primary_id, secondary_id, model_id FROM stock_history WHERE document_id = ?
+	) L
+	LEFT JOIN model M ON M.id = L.model_id
+	ORDER BY L.primary_id ASC
+`
+
+const UNIT_IDENTITY = `
+	SELECT U.primary_id, U.secondary_id, M.id AS model_id, M.name AS model
+	FROM (
+		SELECT primary_id, secondary_id, model_id FROM main_stock WHERE primary_id = ?
+		UNION
+		SELECT primary_id, secondary_id, model_id FROM stock_history WHERE primary_id = ?
+	) U
+	LEFT JOIN model M ON M.id = U.model_id
+	LIMIT 1
+`
+
+const UNIT_CURRENT = `
+	SELECT MS.document_id, DDT.name AS document_type, W.id AS warehouse_id, W.name AS warehouse, MS.price, DD.date, DATEDIFF(NOW(), DD.date) AS in_stock_for
+	FROM main_stock MS
+	LEFT JOIN document DD ON DD.id = MS.document_id
+	LEFT JOIN document_type DDT ON DDT.id = DD.document_type_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
+	WHERE MS.primary_id = ?
+`
+
+const UNIT_RESERVATION = `
+	SELECT R.id, R.primary_id, MS.secondary_id, M.name AS model, W.name AS warehouse, COALESCE(R.customer_id, 0) AS customer_id, COALESCE(C.name, '') AS customer, COALESCE(RW.name, '') AS reserved_for, R.expires_at, R.created_by
+	FROM reservation R
+	LEFT JOIN main_stock MS ON MS.primary_id = R.primary_id
+	LEFT JOIN model M ON M.id = MS.model_id
+	LEFT JOIN document DD ON DD.id = MS.document_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
+	LEFT JOIN customer C ON C.id = R.customer_id
+	LEFT JOIN warehouse RW ON RW.id = R.warehouse_id
+	WHERE R.released_at IS NULL AND R.expires_at > NOW() AND R.primary_id = ?
+`
+
+const UNIT_SALE = `
+	SELECT SI.document_id, DD.date, S.invoice_number, C.id AS customer_id, C.name AS customer, SI.price
+	FROM sale_item SI
+	LEFT JOIN sale S ON S.document_id = SI.document_id
+	LEFT JOIN customer C ON C.id = S.customer_id
+	LEFT JOIN document DD ON DD.id = SI.document_id
+	WHERE SI.primary_id = ?
+	ORDER BY DD.date DESC
+	LIMIT 1
+`
+
+const UNIT_ATTACHMENTS = `
+	SELECT id, name, s3_key, created_by, created_at
+	FROM unit_attachment
+	WHERE primary_id = ?
+	ORDER BY created_at ASC
+`
+
+const UNIT_TIMELINE = `
+	SELECT T.document_id, DDT.name AS document_type, W.id AS warehouse_id, W.name AS warehouse, T.date_in, T.date_out, T.days, T.price
+	FROM (
+		SELECT SH.document_id, SH.date_in, SH.date_out, DATEDIFF(SH.date_out, SH.date_in) AS days, SH.price
+		FROM stock_history SH
+		WHERE SH.primary_id = ?
+		UNION ALL
+		SELECT MS.document_id, DD.date AS date_in, '' AS date_out, DATEDIFF(NOW(), DD.date) AS days, MS.price
+		FROM main_stock MS
+		LEFT JOIN document DD ON DD.id = MS.document_id
+		WHERE MS.primary_id = ?
+	) T
+	LEFT JOIN document DD ON DD.id = T.document_id
+	LEFT JOIN document_type DDT ON DDT.id = DD.document_type_id
+	LEFT JOIN warehouse W ON W.id = DD.warehouse_id
+	ORDER BY T.date_in ASC
+`
+
+const ALL_ITEMS = `
+	SELECT id, name
+	FROM item
+	ORDER BY name ASC
+`
+
+const itemDetails = `
+	SELECT ID.id, ID.item_id, ID.model_id, M.name AS model_name, ID.item_category_id, IC.name AS item_category_name, ID.page_no, ID.item_no, ID.foreign_id, I.name AS item_name, ID.price
+	FROM item_details ID
+	LEFT JOIN item I ON I.id = ID.item_id
+	LEFT JOIN model M ON M.id = ID.model_id
+	LEFT JOIN item_category IC ON IC.id = ID.item_category_id
+`
+
+const ITEM_DETAILS = itemDetails + `WHERE ID.id = ?`
+
+const MODEL_ITEM_DETAILS = itemDetails + `
+	WHERE ID.model_id = ?
+	ORDER BY ID.page_no ASC, ID.item_no ASC
+`
+
+const SEARCH_ITEM_DETAILS = itemDetails + `
+	WHERE ID.foreign_id LIKE ?
+	ORDER BY ID.foreign_id ASC, M.name ASC
+`
+
+const ADD_ITEM_STOCK = `
+	INSERT INTO item_stock (warehouse_id, item_id, quantity)
+	VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)
+`
+
+const REMOVE_ITEM_STOCK = `
+	UPDATE item_stock
+	SET quantity = quantity - ?
+	WHERE warehouse_id = ? AND item_id = ? AND quantity >= ?
+`
+
+const WAREHOUSE_ITEM_STOCK = `
+	SELECT I.id AS item_id, I.name AS item, ST.quantity
+	FROM item_stock ST
+	LEFT JOIN item I ON I.id = ST.item_id
+	WHERE ST.warehouse_id = ? AND ST.quantity <> 0
+	ORDER BY I.name ASC
+`
+
+const ITEM_LEDGER = `
+	SELECT DD.id AS document_id, DDT.name AS document_type, DD.date, W.id AS warehouse_id, W.name AS warehouse, ISL.quantity, COALESCE(ISL.price, '') AS price
+	FROM item_stock_ledger ISL
+	LEFT JOIN document DD ON DD.id = ISL.document_id
+	LEFT JOIN document_type DDT ON DDT.id = DD.document_type_id
+	LEFT JOIN warehouse W ON W.id = ISL.warehouse_id
+	WHERE ISL.item_id = ?
+	ORDER BY DD.date DESC, ISL.id DESC
+`
+
+const MODEL_PRICE_HISTORY = `
+	SELECT MP.id, M.id AS model_id, M.name AS model, MP.cost, MP.dealer, MP.retail, MP.effective_from
+	FROM model_price MP
+	LEFT JOIN model M ON M.id = MP.model_id
+	WHERE MP.model_id = ?
+	ORDER BY MP.effective_from DESC, MP.id DESC
+`
+
+const ACTIVE_MODEL_PRICES = `
+	SELECT MP.id, M.id AS model_id, M.name AS model, MP.cost, MP.dealer, MP.retail, MP.effective_from
+	FROM model_price MP
+	LEFT JOIN model M ON M.id = MP.model_id
+	WHERE MP.id = (
+		SELECT AP.id FROM model_price AP
+		WHERE AP.model_id = MP.model_id AND AP.effective_from <= ?
+		ORDER BY AP.effective_from DESC, AP.id DESC
+		LIMIT 1
+	)
+	ORDER BY M.name ASC
+`
+
+const ACTIVE_COST_PRICE = `
+	SELECT cost
+	FROM model_price
+	WHERE model_id = ? AND effective_from <= ?
+	ORDER BY effective_from DESC, id DESC
+	LIMIT 1
+`
+
+const DOCUMENT_INBOUND = `
+	SELECT DT.inbound, DD.date
+	FROM document DD
+	LEFT JOIN document_type DT ON DT.id = DD.document_type_id
+	WHERE DD.id = ?
+`
+
+const DOCUMENT_UNIT_COSTS = `
+	SELECT primary_id, price FROM main_stock WHERE document_id = ?
+	UNION ALL
+	SELECT primary_id, price FROM stock_history WHERE document_id = ?
+	ORDER BY primary_id ASC
+`
+
+const ADD_MAIN_STOCK_COST = `
+	UPDATE main_stock
+	SET price = price + ?
+	WHERE primary_id = ?
+`
+
+// ADD_STOCK_HISTORY_COST takes the share, the primary id and the goods-in
+// date, leaving the legs of any earlier time the unit was in stock alone
+const ADD_STOCK_HISTORY_COST = `
+	UPDATE stock_history
+	SET price = price + ?
+	WHERE primary_id = ? AND date_in >= ?
+`
+
+const ADD_UNIT_LANDED_COST = `
+	UPDATE unit_cost
+	SET landed_cost = landed_cost + ?
+	WHERE document_id = ? AND primary_id = ?
+`
+
+const DOCUMENT_COSTS = `
+	SELECT id, cost_type, amount, method, created_by, created_at
+	FROM document_cost
+	WHERE document_id = ?
+	ORDER BY created_at ASC
+`
+
+const ALL_DOCUMENT_SERIES = `
+	SELECT DS.id, DS.document_type_id, DT.name AS document_type, DS.warehouse_id, W.name AS warehouse, DS.prefix, DS.padding
+	FROM document_series DS
+	LEFT JOIN document_type DT ON DT.id = DS.document_type_id
+	LEFT JOIN warehouse W ON W.id = DS.warehouse_id
+	ORDER BY W.name, DT.name
+`
+
+const DOCUMENT_SERIES = `
+	SELECT id, prefix, padding
+	FROM document_series
+	WHERE document_type_id = ? AND warehouse_id = ?
+`
+
+const NEXT_DOCUMENT_NUMBER = `
+	INSERT INTO document_series_counter (document_series_id, year, last_number)
+	VALUES (?, ?, LAST_INSERT_ID(1))
+	ON DUPLICATE KEY UPDATE last_number = LAST_INSERT_ID(last_number + 1)
+`
+
+const SEARCH_DOCUMENTS = `
+	SELECT DD.id AS document_id, COALESCE(DD.number, '') AS number, DDT.name AS delivery_document_type, DD.date, W.id AS to_warehouse_id, W.name AS to_warehouse, FW.id AS from_warehouse_id, FW.name AS from_warehouse, COALESCE(NULLIF(DA.status, 'approved'), 'posted') AS status
+	FROM document DD 
+	LEFT JOIN document_type DDT ON DD.document_type_id = DDT.id 
+	LEFT JOIN warehouse W ON DD.warehouse_id = W.id 
+	LEFT JOIN warehouse FW ON DD.from_warehouse_id = FW.id 
+	LEFT JOIN document_approval DA ON DA.document_id = DD.id
+	WHERE DD.number LIKE ?
+	ORDER BY DD.number ASC LIMIT 50
+`
+
+const ALL_DOCUMENT_TYPES = `
+	SELECT DT.id, DT.name, DT.inbound, DT.transfer, DT.outbound, DT.adjustment, DT.requires_approval, COALESCE(DT.source_warehouse_type_id, 0) AS source_warehouse_type_id, COALESCE(SWT.name, '') AS source_warehouse_type, COALESCE(DT.destination_warehouse_type_id, 0) AS destination_warehouse_type_id, COALESCE(DWT.name, '') AS destination_warehouse_type
+	FROM document_type DT
+	LEFT JOIN warehouse_type SWT ON SWT.id = DT.source_warehouse_type_id
+	LEFT JOIN warehouse_type DWT ON DWT.id = DT.destination_warehouse_type_id
+	ORDER BY DT.name ASC
+`
+
+const DOCUMENT_TYPE = `
+	SELECT DT.id, DT.name, DT.inbound, DT.transfer, DT.outbound, DT.adjustment, DT.requires_approval, COALESCE(DT.source_warehouse_type_id, 0) AS source_warehouse_type_id, COALESCE(SWT.name, '') AS source_warehouse_type, COALESCE(DT.destination_warehouse_type_id, 0) AS destination_warehouse_type_id, COALESCE(DWT.name, '') AS destination_warehouse_type
+	FROM document_type DT
+	LEFT JOIN warehouse_type SWT ON SWT.id = DT.source_warehouse_type_id
+	LEFT JOIN warehouse_type DWT ON DWT.id = DT.destination_warehouse_type_id
+	WHERE DT.id = ?
+`
+
+const DOCUMENT_TYPE_FLAGS_FOR_UPDATE = `
+	SELECT inbound, transfer, outbound, adjustment
+	FROM document_type
+	WHERE id = ?
+	FOR UPDATE
+`
+
+const DOCUMENT_TYPE_RULES = `
+	SELECT inbound, transfer, outbound, adjustment, COALESCE(source_warehouse_type_id, 0), COALESCE(destination_warehouse_type_id, 0)
+	FROM document_type
+	WHERE id = ?
+`
+
+const WAREHOUSE_TYPE_OF = `
+	SELECT warehouse_type_id
+	FROM warehouse
+	WHERE id = ?
+`
+
+const DETACH_APPROVAL_RULE = `
+	UPDATE document_approval
+	SET approval_rule_id = NULL
+	WHERE approval_rule_id = ?
+`
+
+const ALL_APPROVAL_RULES = `
+	SELECT AR.id, COALESCE(DT.name, '') AS document_type, COALESCE(FW.name, '') AS from_warehouse, COALESCE(TW.name, '') AS to_warehouse, COALESCE(AR.min_units, 0) AS min_units, COALESCE(AR.min_value, 0) AS min_value, COALESCE(AR.approver, '') AS approver
+	FROM approval_rule AR
+	LEFT JOIN document_type DT ON DT.id = AR.document_type_id
+	LEFT JOIN warehouse FW ON FW.id = AR.from_warehouse_id
+	LEFT JOIN warehouse TW ON TW.id = AR.to_warehouse_id
+	ORDER BY AR.id ASC
+`
+
+const DOCUMENT_TYPE_REQUIRES_APPROVAL = `
+	SELECT requires_approval
+	FROM document_type
+	WHERE id = ?
+`
+
+// MATCHING_APPROVAL_RULE takes the document type, source and destination
+// warehouses, unit count and value of a movement
+const MATCHING_APPROVAL_RULE = `
+	SELECT AR.id, COALESCE(AR.approver, '')
+	FROM approval_rule AR
+	WHERE (AR.document_type_id IS NULL OR AR.document_type_id = ?)
+	AND (AR.from_warehouse_id IS NULL OR AR.from_warehouse_id = ?)
+	AND (AR.to_warehouse_id IS NULL OR AR.to_warehouse_id = ?)
+	AND (AR.min_units IS NULL OR ? >= AR.min_units)
+	AND (AR.min_value IS NULL OR ? >= AR.min_value)
+	ORDER BY AR.id ASC LIMIT 1
+`
+
+const PENDING_APPROVALS = `
+	SELECT DD.id AS document_id, COALESCE(DD.number, '') AS number, DDT.name AS document_type, DD.date, FW.name AS from_warehouse, TW.name AS to_warehouse, COUNT(PI.primary_id) AS units, DA.requested_by, DA.requested_at
+	FROM document_approval DA
+	LEFT JOIN document DD ON DD.id = DA.document_id
+	LEFT JOIN document_type DDT ON DDT.id = DD.document_type_id
+	LEFT JOIN warehouse FW ON FW.id = DD.from_warehouse_id
+	LEFT JOIN warehouse TW ON TW.id = DD.warehouse_id
+	LEFT JOIN document_pending_item PI ON PI.document_id = DA.document_id
+	WHERE DA.status = 'pending' AND DA.requested_by <> ? AND (DA.approver = ? OR (DA.approver IS NULL AND ?))
+	GROUP BY DD.id, DD.number, DDT.name, DD.date, FW.name, TW.name, DA.requested_by, DA.requested_at
+	ORDER BY DA.requested_at ASC
+`
+
+const APPROVAL_FOR_UPDATE = `
+	SELECT DA.status, COALESCE(DA.approver, ''), DA.requested_by, DD.from_warehouse_id, DD.warehouse_id, DD.date
+	FROM document_approval DA
+	LEFT JOIN document DD ON DD.id = DA.document_id
+	WHERE DA.document_id = ?
+	FOR UPDATE
+`
+
+const DOCUMENT_PENDING_ITEMS = `
+	SELECT primary_id
+	FROM document_pending_item
+	WHERE document_id = ?
+`
+
+const DECIDE_APPROVAL = `
+	UPDATE document_approval
+	SET status = ?, decided_by = ?, decided_at = ?, reason = NULLIF(?, '')
+	WHERE document_id = ?
+`
+
+const DRAFT_FOR_UPDATE = `
+	SELECT kind, header, status, created_by
+	FROM draft
+	WHERE id = ?
+	FOR UPDATE
+`
+
+const DRAFT = `
+	SELECT id, kind, header, status, COALESCE(document_id, 0), created_by, created_at, updated_at
+	FROM draft
+	WHERE id = ?
+`
+
+const DRAFT_ITEMS = `
+	SELECT model_id, primary_id, COALESCE(secondary_id, '') AS secondary_id, COALESCE(price, '') AS price
+	FROM draft_item
+	WHERE draft_id = ?
+	ORDER BY id ASC
+`
+
+const DRAFT_HAS_UNIT = `
+	SELECT COUNT(*)
+	FROM draft_item
+	WHERE draft_id = ? AND primary_id = ?
+`
+
+const OPEN_GOODS_IN_DRAFTS_WITH_UNIT = `
+	SELECT COUNT(*)
+	FROM draft_item DI
+	JOIN draft D ON D.id = DI.draft_id
+	WHERE D.kind = 'goods_in' AND D.status = 'open' AND DI.primary_id = ?
+`
+
+const WAREHOUSE_EXISTS = `
+	SELECT COUNT(*)
+	FROM warehouse
+	WHERE id = ?
+`
+
+const UNIT_IN_STOCK = `
+	SELECT COUNT(*)
+	FROM main_stock
+	WHERE primary_id = ?
+`
+
+const OPEN_DRAFTS = `
+	SELECT D.id, D.kind, COUNT(DI.id) AS units, D.created_at, D.updated_at
+	FROM draft D
+	LEFT JOIN draft_item DI ON DI.draft_id = D.id
+	WHERE D.status = 'open' AND D.created_by = ?
+	GROUP BY D.id, D.kind, D.created_at, D.updated_at
+	ORDER BY D.updated_at DESC
+`
+
+const LOCK_DATE = `
+	SELECT DATE_FORMAT(lock_date, '%Y-%m-%d')
+	FROM document_lock
+	ORDER BY id DESC LIMIT 1
+`
+
+const CURRENT_DOCUMENT_LOCK = `
+	SELECT DATE_FORMAT(lock_date, '%Y-%m-%d') AS lock_date, set_by, set_at
+	FROM document_lock
+	ORDER BY id DESC LIMIT 1
+`
+
+// PERIOD_CLOSED counts the closed periods on or after the period given, as
+// closing a month locks every month before it too
+const PERIOD_CLOSED = `
+	SELECT COUNT(*)
+	FROM accounting_period
+	WHERE period >= ?
+`
+
+const PENDING_APPROVALS_BEFORE = `
+	SELECT COUNT(*)
+	FROM document_approval DA
+	JOIN document DD ON DD.id = DA.document_id
+	WHERE DA.status = 'pending' AND DD.date < ?
+`
+
+const OPEN_DRAFT_HEADERS = `
+	SELECT header
+	FROM draft
+	WHERE status = 'open'
+	FOR UPDATE
+`
+
+const CLOSED_PERIODS = `
+	SELECT period, closed_by, closed_at
+	FROM accounting_period
+	ORDER BY period DESC
+`
+
+const SNAPSHOT_BY_WAREHOUSE = `
+	SELECT warehouse_id, warehouse, SUM(count) AS count, SUM(total) AS total, SUM(total) / SUM(count) AS average
+	FROM valuation_snapshot
+	WHERE period = ?
+	GROUP BY warehouse_id, warehouse
+	ORDER BY warehouse ASC
+`
+
+const SNAPSHOT_BY_MODEL = `
+	SELECT model_id, model, SUM(count) AS count, SUM(total) AS total, SUM(total) / SUM(count) AS average
+	FROM valuation_snapshot
+	WHERE period = ?
+	GROUP BY model_id, model
+	ORDER BY model ASC
+`
+
+const SNAPSHOT_BY_WAREHOUSE_MODEL = `
+	SELECT warehouse_id, warehouse, model_id, model, count, total, average
+	FROM valuation_snapshot
+	WHERE period = ?
+	ORDER BY warehouse ASC, model ASC
+`
@@ -0,0 +1,15 @@
+-- One-off repair of stock_history.date_in for legs written with the
+-- "2006-01-02 15:05:05" layout, which put the seconds in place of the
+-- minutes. A leg starts on the date of its document, so those legs are
+-- recognised by matching the document date except for the minutes, which
+-- hold the seconds, and the date is copied back from the document.
+-- Legs in closed periods or on or before the lock date are left as they are.
+UPDATE stock_history SH
+JOIN document DD ON DD.id = SH.document_id
+SET SH.date_in = DD.date
+WHERE SH.date_in <> DD.date
+AND DATE_FORMAT(SH.date_in, '%Y-%m-%d %H') = DATE_FORMAT(DD.date, '%Y-%m-%d %H')
+AND MINUTE(SH.date_in) = SECOND(DD.date)
+AND SECOND(SH.date_in) = SECOND(DD.date)
+AND DATE_FORMAT(DD.date, '%Y-%m') > (SELECT COALESCE(MAX(period), '') FROM accounting_period)
+AND DATE(DD.date) > COALESCE((SELECT lock_date FROM document_lock ORDER BY id DESC LIMIT 1), '1000-01-01');
@@ -19,23 +19,95 @@ func (app *application) routes() http.Handler {
 	r.Handle("/model/create", app.validateToken(http.HandlerFunc(app.createModel))).Methods("POST")
 	r.Handle("/user/create", app.validateToken(http.HandlerFunc(app.craeteUser))).Methods("POST")
 	r.Handle("/model/all", app.validateToken(http.HandlerFunc(app.allItems))).Methods("GET")
+	r.Handle("/model/price/create", app.validateToken(http.HandlerFunc(app.createModelPrice))).Methods("POST")
+	r.Handle("/model/price/active", app.validateToken(http.HandlerFunc(app.activeModelPrices))).Methods("GET")
+	r.Handle("/model/price/{id}", app.validateToken(http.HandlerFunc(app.modelPriceHistory))).Methods("GET")
 	r.Handle("/user/all", app.validateToken(http.HandlerFunc(app.allUser))).Methods("GET")
 	r.Handle("/docs/recent", app.validateToken(http.HandlerFunc(app.recentDocs))).Methods("GET")
 	r.Handle("/stock/bymodel", app.validateToken(http.HandlerFunc(app.stockByModel))).Methods("GET")
 	r.Handle("/stock/bywarehouse", app.validateToken(http.HandlerFunc(app.stocksByWarehouse))).Methods("GET")
+	r.Handle("/stock/asat", app.validateToken(http.HandlerFunc(app.stockAsAt))).Methods("GET")
 	r.Handle("/warehouse/create", app.validateToken(http.HandlerFunc(app.createWarehouse))).Methods("POST")
 	r.Handle("/warehouse/all", app.validateToken(http.HandlerFunc(app.allWarehouses))).Methods("GET")
 	r.Handle("/warehouse/stock/{id}", app.validateToken(http.HandlerFunc(app.warehouseStock))).Methods("GET")
 	r.Handle("/history/{id}", app.validateToken(http.HandlerFunc(app.history))).Methods("GET")
 	r.Handle("/search", app.validateToken(http.HandlerFunc(app.search))).Methods("GET")
 	r.Handle("/agewise", app.validateToken(http.HandlerFunc(app.ageWise))).Methods("GET")
+	r.Handle("/sale/{id}", app.validateToken(http.HandlerFunc(app.saleDetails))).Methods("GET")
+	r.Handle("/customer/create", app.validateToken(http.HandlerFunc(app.createCustomer))).Methods("POST")
+	r.Handle("/customer/search", app.validateToken(http.HandlerFunc(app.searchCustomers))).Methods("GET")
+	r.Handle("/customer/purchases/{id}", app.validateToken(http.HandlerFunc(app.customerPurchases))).Methods("GET")
+	r.Handle("/customer/{id}", app.validateToken(http.HandlerFunc(app.customerDetails))).Methods("GET")
+	r.Handle("/supplier/create", app.validateToken(http.HandlerFunc(app.createSupplier))).Methods("POST")
+	r.Handle("/supplier/all", app.validateToken(http.HandlerFunc(app.allSuppliers))).Methods("GET")
+	r.Handle("/purchaseorder/create", app.validateToken(http.HandlerFunc(app.createPurchaseOrder))).Methods("POST")
+	r.Handle("/purchaseorder/outstanding", app.validateToken(http.HandlerFunc(app.outstandingPurchases))).Methods("GET")
+	r.Handle("/purchaseorder/{id}", app.validateToken(http.HandlerFunc(app.purchaseOrder))).Methods("GET")
+	r.Handle("/reservation/create", app.validateToken(http.HandlerFunc(app.createReservation))).Methods("POST")
+	r.Handle("/reservation/release/{id}", app.validateToken(http.HandlerFunc(app.releaseReservation))).Methods("POST")
+	r.Handle("/reservation/active", app.validateToken(http.HandlerFunc(app.activeReservations))).Methods("GET")
+	r.Handle("/report/valuation", app.validateToken(http.HandlerFunc(app.valuation))).Methods("GET")
+	r.Handle("/report/ageing", app.validateToken(http.HandlerFunc(app.ageing))).Methods("GET")
+	r.Handle("/report/movement", app.validateToken(http.HandlerFunc(app.movementSummary))).Methods("GET")
+	r.Handle("/reorder/set", app.validateToken(app.requireAdmin(http.HandlerFunc(app.setReorderLevel)))).Methods("POST")
+	r.Handle("/reorder/all", app.validateToken(http.HandlerFunc(app.allReorderLevels))).Methods("GET")
+	r.Handle("/reorder/suggestions", app.validateToken(http.HandlerFunc(app.reorderSuggestions))).Methods("GET")
+	r.Handle("/item/category/create", app.validateToken(http.HandlerFunc(app.createItemCategory))).Methods("POST")
+	r.Handle("/item/create", app.validateToken(http.HandlerFunc(app.createItem))).Methods("POST")
+	r.Handle("/item/all", app.validateToken(http.HandlerFunc(app.allCatalogItems))).Methods("GET")
+	r.Handle("/item/search", app.validateToken(http.HandlerFunc(app.searchItemDetails))).Methods("GET")
+	r.Handle("/item/details/create", app.validateToken(http.HandlerFunc(app.createItemDetails))).Methods("POST")
+	r.Handle("/item/details/update/{id}", app.validateToken(http.HandlerFunc(app.updateItemDetails))).Methods("POST")
+	r.Handle("/item/details/delete/{id}", app.validateToken(http.HandlerFunc(app.deleteItemDetails))).Methods("POST")
+	r.Handle("/item/details/model/{id}", app.validateToken(http.HandlerFunc(app.modelItemDetails))).Methods("GET")
+	r.Handle("/item/details/{id}", app.validateToken(http.HandlerFunc(app.itemDetails))).Methods("GET")
+	r.Handle("/item/stock/{id}", app.validateToken(http.HandlerFunc(app.itemStock))).Methods("GET")
+	r.Handle("/item/ledger/{id}", app.validateToken(http.HandlerFunc(app.itemLedger))).Methods("GET")
+	r.Handle("/lookup", app.validateToken(http.HandlerFunc(app.lookup))).Methods("GET")
+	r.Handle("/lookup/primary/{number}", app.validateToken(http.HandlerFunc(app.lookupPrimary))).Methods("GET")
+	r.Handle("/lookup/secondary/{number}", app.validateToken(http.HandlerFunc(app.lookupSecondary))).Methods("GET")
+	r.Handle("/units/{primary_id}", app.validateToken(http.HandlerFunc(app.unitDetail))).Methods("GET")
+	r.Handle("/units/{primary_id}/label", app.validateToken(http.HandlerFunc(app.unitLabel))).Methods("GET")
+	r.Handle("/units/{primary_id}/attachment", app.validateToken(http.HandlerFunc(app.uploadUnitAttachment))).Methods("POST")
+	r.Handle("/draft/create", app.validateToken(http.HandlerFunc(app.createDraft))).Methods("POST")
+	r.Handle("/draft/open", app.validateToken(http.HandlerFunc(app.openDrafts))).Methods("GET")
+	r.Handle("/draft/{id}/add", app.validateToken(http.HandlerFunc(app.addDraftItem))).Methods("POST")
+	r.Handle("/draft/{id}/remove", app.validateToken(http.HandlerFunc(app.removeDraftItem))).Methods("POST")
+	r.Handle("/draft/{id}/post", app.validateToken(http.HandlerFunc(app.postDraft))).Methods("POST")
+	r.Handle("/draft/{id}/discard", app.validateToken(http.HandlerFunc(app.discardDraft))).Methods("POST")
+	r.Handle("/draft/{id}", app.validateToken(http.HandlerFunc(app.draft))).Methods("GET")
+	r.Handle("/approval/rule/create", app.validateToken(app.requireAdmin(http.HandlerFunc(app.createApprovalRule)))).Methods("POST")
+	r.Handle("/approval/rule/delete/{id}", app.validateToken(app.requireAdmin(http.HandlerFunc(app.deleteApprovalRule)))).Methods("POST")
+	r.Handle("/approval/rule/all", app.validateToken(http.HandlerFunc(app.approvalRules))).Methods("GET")
+	r.Handle("/approval/pending", app.validateToken(http.HandlerFunc(app.pendingApprovals))).Methods("GET")
+	r.Handle("/approval/approve/{id}", app.validateToken(http.HandlerFunc(app.approveDocument))).Methods("POST")
+	r.Handle("/approval/reject/{id}", app.validateToken(http.HandlerFunc(app.rejectDocument))).Methods("POST")
+	r.Handle("/document/type/create", app.validateToken(app.requireAdmin(http.HandlerFunc(app.createDocumentType)))).Methods("POST")
+	r.Handle("/document/type/update/{id}", app.validateToken(app.requireAdmin(http.HandlerFunc(app.updateDocumentType)))).Methods("POST")
+	r.Handle("/document/type/delete/{id}", app.validateToken(app.requireAdmin(http.HandlerFunc(app.deleteDocumentType)))).Methods("POST")
+	r.Handle("/document/type/all", app.validateToken(http.HandlerFunc(app.allDocumentTypes))).Methods("GET")
+	r.Handle("/document/type/{id}", app.validateToken(http.HandlerFunc(app.documentTypeDetails))).Methods("GET")
+	r.Handle("/document/lock", app.validateToken(http.HandlerFunc(app.lockDate))).Methods("GET")
+	r.Handle("/document/lock", app.validateToken(app.requireAdmin(http.HandlerFunc(app.setLockDate)))).Methods("POST")
+	r.Handle("/period/close", app.validateToken(app.requireAdmin(http.HandlerFunc(app.closePeriod)))).Methods("POST")
+	r.Handle("/period/all", app.validateToken(http.HandlerFunc(app.periods))).Methods("GET")
+	r.Handle("/period/{period}/valuation", app.validateToken(http.HandlerFunc(app.periodValuation))).Methods("GET")
+	r.Handle("/document/series/create", app.validateToken(http.HandlerFunc(app.createDocumentSeries))).Methods("POST")
+	r.Handle("/document/series/all", app.validateToken(http.HandlerFunc(app.allDocumentSeries))).Methods("GET")
+	r.Handle("/document/search", app.validateToken(http.HandlerFunc(app.searchDocuments))).Methods("GET")
+	r.Handle("/document/{id}/labels", app.validateToken(http.HandlerFunc(app.documentLabels))).Methods("GET")
+	r.Handle("/document/{id}/cost", app.validateToken(app.requireAdmin(http.HandlerFunc(app.addDocumentCost)))).Methods("POST")
+	r.Handle("/document/{id}/costs", app.validateToken(http.HandlerFunc(app.documentCosts))).Methods("GET")
 
 	r.Handle("/transactions/goodsin", app.validateToken(http.HandlerFunc(app.goodsIn))).Methods("POST")
 	r.Handle("/transactions/movement", app.validateToken(http.HandlerFunc(app.transaction))).Methods("POST")
-	r.Handle("/getSecondaryNumberModelName", app.validateToken(http.HandlerFunc(app.secNumberModel))).Methods("POST")
+	r.Handle("/transactions/sale", app.validateToken(http.HandlerFunc(app.sale))).Methods("POST")
+	r.Handle("/transactions/item/goodsin", app.validateToken(http.HandlerFunc(app.itemGoodsIn))).Methods("POST")
+	r.Handle("/transactions/item/movement", app.validateToken(http.HandlerFunc(app.itemMovement))).Methods("POST")
+	r.Handle("/transactions/item/issue", app.validateToken(http.HandlerFunc(app.itemIssue))).Methods("POST")
 
 	fileServer := http.FileServer(http.Dir("./ui/static/"))
 	r.Handle("/static/", http.StripPrefix("/static", fileServer))
 
-	return standardMiddleware.Then(handlers.CORS(handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"}), handlers.AllowedMethods([]string{"GET", "POST", "PUT", "HEAD", "OPTIONS"}), handlers.AllowedOrigins([]string{"*"}))(r))
+	return standardMiddleware.Then(handlers.CORS(handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"}), handlers.AllowedMethods([]string{"GET", "POST", "PUT", "HEAD", "OPTIONS"}), handlers.AllowedOrigins([]string{"*"}), handlers.ExposedHeaders([]string{"X-Total-Count"}))(r))
 }